@@ -0,0 +1,64 @@
+package jsonmuncher
+
+import (
+	"math/big"
+	"unsafe"
+)
+
+// ValueBigInt reads the value of a Number into the given big.Int, at full
+// precision. If the literal has a fraction or an exponent, an ErrNumRange is
+// returned. Like ValueNumRaw(), this relies on the literal text, which is only
+// retained until the next Number is read from the stream.
+func (data *JsonValue) ValueBigInt(z *big.Int) error {
+	lit, err := numLiteral(data)
+	if err != nil {
+		return err
+	} else if !data.keynext {
+		return ErrNumRange{"big.Int", true, data.Path()}
+	} else if data.boolval {
+		z.SetUint64(data.num)
+		if data.neg {
+			z.Neg(z)
+		}
+		return nil
+	} else if lit == nil {
+		return withPath(data, ErrLiteralDiscarded)
+	}
+	// See readNumber for an explanation of this cast.
+	z.SetString(*(*string)(noescape(unsafe.Pointer(&lit))), 10)
+	return nil
+}
+
+// ValueBigFloat reads the value of a Number into the given big.Float. The
+// exponent range of a big.Float is far larger than that of a float64, so this
+// can be used for literals that ValueNum() reports as out of range. If the
+// precision of f is 0, it is set to a precision large enough to hold every
+// digit of the literal; otherwise the value is rounded to the precision and
+// rounding mode of f. Like ValueNumRaw(), this relies on the literal text, which
+// is only retained until the next Number is read from the stream.
+func (data *JsonValue) ValueBigFloat(f *big.Float) error {
+	lit, err := numLiteral(data)
+	if err != nil {
+		return err
+	} else if lit == nil && !(data.keynext && data.boolval) {
+		return withPath(data, ErrLiteralDiscarded)
+	}
+	if f.Prec() == 0 {
+		// Each decimal digit needs a little less than 4 bits.
+		prec := uint(4 * len(lit))
+		if prec < 64 {
+			prec = 64
+		}
+		f.SetPrec(prec)
+	}
+	if lit == nil {
+		f.SetUint64(data.num)
+		if data.neg {
+			f.Neg(f)
+		}
+		return nil
+	}
+	// See readNumber for an explanation of this cast.
+	_, _, err = f.Parse(*(*string)(noescape(unsafe.Pointer(&lit))), 10)
+	return err
+}
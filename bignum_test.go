@@ -0,0 +1,66 @@
+package jsonmuncher
+
+import (
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestBigNumbers(t *testing.T) {
+	json := "[-123456789012345678901234567890123456789, 1e400, 2.5e-3, 42, 1.5]"
+	r := strings.NewReader(json)
+	v1, _ := Parse(r, 16)
+	v2, _ := v1.NextValue()
+	var z big.Int
+	e := v2.ValueBigInt(&z)
+	assert(t, z.String() != "-123456789012345678901234567890123456789" || e != nil,
+		"1", z.String(), e)
+	var f big.Float
+	e = v2.ValueBigFloat(&f)
+	assert(t, f.Text('f', 0) != "-123456789012345678901234567890123456789" || e != nil,
+		"2", f.Text('f', 0), e)
+	v2, _ = v1.NextValue()
+	vn, en := v2.ValueNum()
+	assert(t, !math.IsInf(vn, 1) || en != (ErrNumRange{"float64", false, ""}) || v2.Status != Complete,
+		"3", vn, en, v2.Status)
+	f = big.Float{}
+	e = v2.ValueBigFloat(&f)
+	assert(t, f.Text('g', 10) != "1e+400" || e != nil,
+		"4", f.Text('g', 10), e)
+	e = v2.ValueBigInt(&z)
+	assert(t, e != (ErrNumRange{"big.Int", true, ""}),
+		"5", e)
+	v2, _ = v1.NextValue()
+	f.SetPrec(53)
+	e = v2.ValueBigFloat(&f)
+	x, _ := f.Float64()
+	assert(t, x != 2.5e-3 || e != nil,
+		"6", x, e)
+	v2, _ = v1.NextValue()
+	v2.ValueUint64()
+	v3, _ := v1.NextValue()
+	v3.ValueNum()
+	e = v2.ValueBigInt(&z)
+	assert(t, z.Int64() != 42 || e != nil,
+		"7", z.String(), e)
+	f = big.Float{}
+	e = v2.ValueBigFloat(&f)
+	assert(t, f.Text('g', 10) != "42" || e != nil,
+		"8", f.Text('g', 10), e)
+	_, e = v1.NextValue()
+	assert(t, e != EndOfValue,
+		"9", e)
+	r = strings.NewReader("[1.25, 1]")
+	v1, _ = Parse(r, 16)
+	v2, _ = v1.NextValue()
+	v2.ValueNum()
+	v3, _ = v1.NextValue()
+	v3.ValueNum()
+	e = v2.ValueBigFloat(&f)
+	assert(t, e != ErrLiteralDiscarded,
+		"10", e)
+	e = v1.ValueBigInt(&z)
+	assert(t, e == nil || e.Error() != "Method cannot be called on type Array, only on Number",
+		"11", e)
+}
@@ -0,0 +1,477 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// importPath is the import path of the jsonmuncher package, as used by the
+// generated code.
+const importPath = "github.com/darthfennec/jsonmuncher"
+
+// generator holds the state of a run.
+type generator struct {
+	buf bytes.Buffer
+	// structs holds every struct type declared in the package, and targets the
+	// ones that get a MunchJSON method.
+	structs map[string]*ast.StructType
+	targets map[string]bool
+	// types holds every type declared in the package.
+	types map[string]*ast.TypeSpec
+	// tmp is used to give local variables in the generated code unique names.
+	tmp int
+}
+
+// alloc is an embedded struct pointer, which has to be allocated before one of
+// its fields can be set.
+type alloc struct {
+	expr string
+	typ  string
+}
+
+// field is a struct field that a key can be decoded into. expr is the field as
+// a Go expression, such as "out.Metadata.Name", and depth is the number of
+// embedded structs it was promoted through.
+type field struct {
+	key    string
+	expr   string
+	allocs []alloc
+	typ    ast.Expr
+	quoted bool
+	tagged bool
+	depth  int
+}
+
+// generate parses the input files, and returns the formatted source of the
+// decoders for the named struct types, or for every struct type declared in the
+// input files if names is nil. The rest of the package is loaded as well, so
+// that structs embedded from its other files can be promoted.
+func generate(files []string, names []string) ([]byte, error) {
+	fset := token.NewFileSet()
+	g := &generator{structs: map[string]*ast.StructType{}, targets: map[string]bool{},
+		types: map[string]*ast.TypeSpec{}}
+	var pkg string
+	var order []string
+	inputs := map[string]bool{}
+	add := func(name string, input bool) error {
+		f, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			return err
+		} else if pkg == "" {
+			pkg = f.Name.Name
+		} else if f.Name.Name != pkg {
+			return fmt.Errorf("%s: package %s, expected %s", name, f.Name.Name, pkg)
+		}
+		for _, decl := range f.Decls {
+			gd, ok := decl.(*ast.GenDecl)
+			if !ok || gd.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gd.Specs {
+				ts := spec.(*ast.TypeSpec)
+				g.types[ts.Name.Name] = ts
+				st, ok := ts.Type.(*ast.StructType)
+				if ok && ts.TypeParams == nil && !ts.Assign.IsValid() {
+					g.structs[ts.Name.Name] = st
+					if input {
+						order = append(order, ts.Name.Name)
+					}
+				}
+			}
+		}
+		return nil
+	}
+	for _, name := range files {
+		if filepath.Dir(name) != filepath.Dir(files[0]) {
+			return nil, fmt.Errorf("%s: not in the same directory as %s", name, files[0])
+		}
+		inputs[filepath.Base(name)] = true
+		err := add(name, true)
+		if err != nil {
+			return nil, err
+		}
+	}
+	dir := filepath.Dir(files[0])
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, fmt.Errorf("loading package: %v", err)
+	}
+	for _, name := range append(bp.GoFiles, bp.CgoFiles...) {
+		if !inputs[name] {
+			err = add(filepath.Join(dir, name), false)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	if names == nil {
+		names = order
+	}
+	for _, name := range names {
+		if g.structs[name] == nil {
+			return nil, fmt.Errorf("struct type %s not found", name)
+		}
+		g.targets[name] = true
+	}
+	fmt.Fprintf(&g.buf, "// Code generated by jsonmunchergen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&g.buf, "package %s\n\nimport %q\n", pkg, importPath)
+	done := map[string]bool{}
+	for _, name := range names {
+		if done[name] {
+			continue
+		}
+		done[name] = true
+		err := g.genStruct(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	src, err := format.Source(g.buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting output: %v", err)
+	}
+	return src, nil
+}
+
+// next returns a new number for naming local variables.
+func (g *generator) next() int {
+	g.tmp++
+	return g.tmp
+}
+
+// collect adds the fields of a struct type, and those of its embedded structs,
+// to the list of candidates. The types being visited are tracked, so a struct
+// that embeds itself doesn't recurse forever.
+func (g *generator) collect(name string, prefix string, allocs []alloc, depth int,
+	visiting map[string]bool, cands *[]field) error {
+	if visiting[name] {
+		return nil
+	}
+	visiting[name] = true
+	defer delete(visiting, name)
+	for _, f := range g.structs[name].Fields.List {
+		var tag string
+		if f.Tag != nil {
+			s, _ := strconv.Unquote(f.Tag.Value)
+			tag = reflect.StructTag(s).Get("json")
+		}
+		if tag == "-" {
+			continue
+		}
+		key, opts, _ := strings.Cut(tag, ",")
+		var names []string
+		for _, n := range f.Names {
+			names = append(names, n.Name)
+		}
+		if len(f.Names) == 0 {
+			typ, ptr := f.Type, false
+			if star, ok := typ.(*ast.StarExpr); ok {
+				typ, ptr = star.X, true
+			}
+			var tname string
+			switch t := typ.(type) {
+			case *ast.Ident:
+				tname = t.Name
+				if key != "" {
+					break
+				}
+				sname, err := g.embedded(name, tname)
+				if err != nil {
+					return err
+				} else if sname != "" {
+					// An unexported embedded pointer can't be allocated, so its
+					// fields can't be set.
+					if ptr && !ast.IsExported(tname) {
+						continue
+					}
+					expr := prefix + "." + tname
+					next := allocs
+					if ptr {
+						next = append(append([]alloc(nil), allocs...), alloc{expr, sname})
+					}
+					err = g.collect(sname, expr, next, depth+1, visiting, cands)
+					if err != nil {
+						return err
+					}
+					continue
+				}
+			case *ast.SelectorExpr:
+				tname = t.Sel.Name
+				if key == "" {
+					return fmt.Errorf("%s: embedded type %s is from another package, so its fields can't be promoted",
+						name, types.ExprString(t))
+				}
+			default:
+				return fmt.Errorf("%s: unsupported embedded type %s", name, types.ExprString(f.Type))
+			}
+			names = []string{tname}
+		}
+		for _, n := range names {
+			if !ast.IsExported(n) {
+				continue
+			}
+			fd := field{key: key, expr: prefix + "." + n, allocs: allocs, typ: f.Type,
+				tagged: key != "", depth: depth}
+			if key == "" {
+				fd.key = n
+			}
+			for _, opt := range strings.Split(opts, ",") {
+				if opt == "string" {
+					fd.quoted = quotable(f.Type)
+				}
+			}
+			*cands = append(*cands, fd)
+		}
+	}
+	return nil
+}
+
+// embedded returns the struct type that the type of an embedded field refers
+// to, following any aliases, or "" if it isn't a struct. An error is returned
+// if the type isn't declared in the package, or is declared in another one,
+// since its fields can't be found.
+func (g *generator) embedded(name string, tname string) (string, error) {
+	for i := 0; i <= len(g.types); i++ {
+		ts := g.types[tname]
+		if ts == nil {
+			if types.Universe.Lookup(tname) == nil {
+				return "", fmt.Errorf("%s: embedded type %s can't be resolved", name, tname)
+			}
+			return "", nil
+		} else if g.structs[tname] != nil {
+			return tname, nil
+		} else if !ts.Assign.IsValid() {
+			return "", nil
+		}
+		switch t := ts.Type.(type) {
+		case *ast.Ident:
+			tname = t.Name
+		case *ast.SelectorExpr:
+			return "", fmt.Errorf("%s: embedded type %s is from another package, so its fields can't be promoted",
+				name, types.ExprString(t))
+		default:
+			return "", nil
+		}
+	}
+	// The aliases refer to each other in a loop.
+	return "", nil
+}
+
+// quotable reports whether the ",string" option applies to a type.
+func quotable(typ ast.Expr) bool {
+	if star, ok := typ.(*ast.StarExpr); ok {
+		typ = star.X
+	}
+	id, ok := typ.(*ast.Ident)
+	if !ok {
+		return false
+	}
+	switch id.Name {
+	case "bool", "string", "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "float32", "float64":
+		return true
+	}
+	return false
+}
+
+// resolve settles conflicts between fields with the same key, following the
+// rules of encoding/json: the least deeply nested field is used, then the
+// tagged one. If that still leaves more than one, none of them are used. The
+// fields are returned sorted by key.
+func resolve(cands []field) []field {
+	sort.SliceStable(cands, func(i, j int) bool {
+		a, b := &cands[i], &cands[j]
+		if a.key != b.key {
+			return a.key < b.key
+		} else if a.depth != b.depth {
+			return a.depth < b.depth
+		}
+		return a.tagged && !b.tagged
+	})
+	var fields []field
+	for i := 0; i < len(cands); {
+		j := i + 1
+		for j < len(cands) && cands[j].key == cands[i].key {
+			j++
+		}
+		if j == i+1 || cands[i+1].depth > cands[i].depth ||
+			cands[i].tagged && !cands[i+1].tagged {
+			fields = append(fields, cands[i])
+		}
+		i = j
+	}
+	return fields
+}
+
+// genStruct writes the MunchJSON method for a struct type, along with the
+// sorted list of its keys, which Compare() uses to match keys as they are
+// streamed in. The list is shared by every call, and Compare() sorts its
+// arguments in place, but resolve() has already sorted it, and an insertion
+// sort of a list that is already sorted only reads it, which is safe to do
+// concurrently.
+func (g *generator) genStruct(name string) error {
+	var cands []field
+	err := g.collect(name, "out", nil, 0, map[string]bool{}, &cands)
+	if err != nil {
+		return err
+	}
+	fields := resolve(cands)
+	w := &g.buf
+	keys := "munchKeys" + name
+	if len(fields) > 0 {
+		fmt.Fprintf(w, "\n// %s holds the keys of %s, sorted for Compare. They must stay\n", keys, name)
+		fmt.Fprintf(w, "// sorted: Compare sorts its arguments in place, and only leaves a sorted slice\n")
+		fmt.Fprintf(w, "// untouched, which lets concurrent calls share it.\n")
+		fmt.Fprintf(w, "var %s = []string{", keys)
+		for i, f := range fields {
+			if i > 0 {
+				fmt.Fprintf(w, ", ")
+			}
+			fmt.Fprintf(w, "%q", f.key)
+		}
+		fmt.Fprintf(w, "}\n")
+	}
+	fmt.Fprintf(w, "\n// MunchJSON reads the fields of %s from v. Keys that don't match a field are\n", name)
+	fmt.Fprintf(w, "// discarded.\n")
+	fmt.Fprintf(w, "func (out *%s) MunchJSON(v *jsonmuncher.JsonValue) error {\n", name)
+	fmt.Fprintf(w, "if v.Type == jsonmuncher.Null {\nreturn nil\n} else if v.Type != jsonmuncher.Object {\n")
+	fmt.Fprintf(w, "return jsonmuncher.ErrUnmarshalType{Provided: v.Type, Target: %q, Path: v.Path()}\n}\n", name)
+	fmt.Fprintf(w, "for {\nkey, err := v.NextKey()\nif err == jsonmuncher.EndOfValue {\n")
+	fmt.Fprintf(w, "return nil\n} else if err != nil {\nreturn err\n}\n")
+	if len(fields) == 0 {
+		fmt.Fprintf(w, "err = key.Close()\nif err != nil {\nreturn err\n}\n}\n}\n")
+		return nil
+	}
+	fmt.Fprintf(w, "k, ok, err := key.Compare(%s...)\nif err != nil {\nreturn err\n", keys)
+	fmt.Fprintf(w, "} else if !ok {\n// The value is discarded by the next call to NextKey.\ncontinue\n}\n")
+	fmt.Fprintf(w, "val, err := v.NextValue()\nif err != nil {\nreturn err\n}\nswitch k {\n")
+	for _, f := range fields {
+		fmt.Fprintf(w, "case %q:\n", f.key)
+		for _, a := range f.allocs {
+			fmt.Fprintf(w, "if %s == nil {\n%s = new(%s)\n}\n", a.expr, a.expr, a.typ)
+		}
+		if !g.decode("val", f.expr, f.typ, f.quoted, false) {
+			fmt.Fprintf(w, "if err == nil {\nerr = val.Close()\n}\n")
+		}
+	}
+	fmt.Fprintf(w, "}\nif err != nil {\nreturn err\n}\n}\n}\n")
+	return nil
+}
+
+// valueMethods maps the basic types that can be read directly to the JsonValue
+// methods that read them.
+var valueMethods = map[string]string{
+	"bool":    "ValueBool",
+	"string":  "ValueString",
+	"float64": "ValueNum",
+	"int64":   "ValueInt64",
+	"uint64":  "ValueUint64",
+}
+
+// decode writes the code to read the JsonValue named val into the Go value
+// target, of the given type, setting err. If notnull is set, val is already
+// known not to be Null. val is closed by the caller afterwards, unless it is
+// handed to Unmarshal, which reads it through a copy; then true is returned, and
+// val must not be used again. If quoted is set, a String holds the value, as
+// with the ",string" option; as with Unmarshal, any other value is read as it
+// is.
+func (g *generator) decode(val string, target string, typ ast.Expr, quoted bool, notnull bool) bool {
+	w := &g.buf
+	if quoted {
+		n := g.next()
+		fmt.Fprintf(w, "if %s.Type == jsonmuncher.String {\n", val)
+		fmt.Fprintf(w, "var s%d string\ns%d, err = %s.ValueString()\nif err == nil {\n", n, n, val)
+		fmt.Fprintf(w, "var inner%d jsonmuncher.JsonValue\ninner%d, err = jsonmuncher.ParseBytes([]byte(s%d))\n", n, n, n)
+		fmt.Fprintf(w, "if err == nil {\n")
+		g.decode(fmt.Sprintf("inner%d", n), target, typ, false, false)
+		fmt.Fprintf(w, "}\nif err == nil {\nerr = inner%d.Finish()\n}\n}\n} else {\n", n)
+		// The String has been read in its entirety, so closing it is harmless
+		// whichever way the other branch goes.
+		unmarshaled := g.decode(val, target, typ, false, notnull)
+		fmt.Fprintf(w, "}\n")
+		return unmarshaled
+	}
+	switch t := typ.(type) {
+	case *ast.Ident:
+		if method, ok := valueMethods[t.Name]; ok {
+			if notnull {
+				fmt.Fprintf(w, "%s, err = %s.%s()\n", target, val, method)
+			} else {
+				fmt.Fprintf(w, "if %s.Type != jsonmuncher.Null {\n%s, err = %s.%s()\n}\n", val, target, val, method)
+			}
+			return false
+		}
+		switch t.Name {
+		case "int", "int8", "int16", "int32", "uint", "uint8", "uint16", "uint32":
+			// The value is read at full width, and checked against the range of
+			// the narrower type.
+			wide, method := "int64", "ValueInt64"
+			if t.Name[0] == 'u' {
+				wide, method = "uint64", "ValueUint64"
+			}
+			n := g.next()
+			if !notnull {
+				fmt.Fprintf(w, "if %s.Type != jsonmuncher.Null {\n", val)
+			}
+			fmt.Fprintf(w, "var n%d %s\nn%d, err = %s.%s()\n", n, wide, n, val, method)
+			fmt.Fprintf(w, "if err == nil && %s(%s(n%d)) != n%d {\n", wide, t.Name, n, n)
+			fmt.Fprintf(w, "err = jsonmuncher.ErrNumRange{Target: %q, Path: %s.Path()}\n} else if err == nil {\n", t.Name, val)
+			fmt.Fprintf(w, "%s = %s(n%d)\n}\n", target, t.Name, n)
+			if !notnull {
+				fmt.Fprintf(w, "}\n")
+			}
+			return false
+		}
+		if g.targets[t.Name] {
+			fmt.Fprintf(w, "err = %s.MunchJSON(&%s)\n", target, val)
+			return false
+		}
+	case *ast.StarExpr:
+		fmt.Fprintf(w, "if %s.Type == jsonmuncher.Null {\n%s = nil\n} else {\n", val, target)
+		fmt.Fprintf(w, "if %s == nil {\n%s = new(%s)\n}\n", target, target, types.ExprString(t.X))
+		elem := "*" + target
+		if id, ok := t.X.(*ast.Ident); ok && g.targets[id.Name] {
+			// MunchJSON can be called through the pointer.
+			elem = target
+		} else if _, ok := t.X.(*ast.ArrayType); ok {
+			elem = "(*" + target + ")"
+		}
+		unmarshaled := g.decode(val, elem, t.X, false, true)
+		fmt.Fprintf(w, "}\n")
+		return unmarshaled
+	case *ast.ArrayType:
+		if id, ok := t.Elt.(*ast.Ident); t.Len != nil || ok && (id.Name == "byte" || id.Name == "uint8") {
+			// Arrays, and byte slices (which are base64 encoded), are left to
+			// Unmarshal.
+			break
+		}
+		n := g.next()
+		fmt.Fprintf(w, "if %s.Type == jsonmuncher.Null {\n%s = nil\n", val, target)
+		fmt.Fprintf(w, "} else if %s.Type != jsonmuncher.Array {\n", val)
+		fmt.Fprintf(w, "err = jsonmuncher.ErrUnmarshalType{Provided: %s.Type, Target: %q, Path: %s.Path()}\n",
+			val, types.ExprString(t), val)
+		fmt.Fprintf(w, "} else {\nif %s == nil {\n%s = %s{}\n} else {\n%s = %s[:0]\n}\n",
+			target, target, types.ExprString(t), target, target)
+		fmt.Fprintf(w, "for {\nvar elem%d jsonmuncher.JsonValue\nelem%d, err = %s.NextValue()\n", n, n, val)
+		fmt.Fprintf(w, "if err == jsonmuncher.EndOfValue {\nerr = nil\nbreak\n} else if err != nil {\nbreak\n}\n")
+		fmt.Fprintf(w, "var e%d %s\n", n, types.ExprString(t.Elt))
+		if !g.decode(fmt.Sprintf("elem%d", n), fmt.Sprintf("e%d", n), t.Elt, false, false) {
+			fmt.Fprintf(w, "if err == nil {\nerr = elem%d.Close()\n}\n", n)
+		}
+		fmt.Fprintf(w, "if err != nil {\nbreak\n}\n%s = append(%s, e%d)\n}\n}\n", target, target, n)
+		return false
+	}
+	fmt.Fprintf(w, "err = jsonmuncher.Unmarshal(%s, &%s)\n", val, target)
+	return true
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func TestGenerate(t *testing.T) {
+	src, err := generate([]string{"testdata/records/records.go"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		err = os.WriteFile("testdata/records/records_munch.go", src, 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	golden, err := os.ReadFile("testdata/records/records_munch.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(src, golden) {
+		t.Errorf("generated code doesn't match testdata/records/records_munch.go; run with -update to see the difference")
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		files []string
+		types []string
+		err   string
+	}{
+		{[]string{"testdata/records/records.go"}, []string{"Missing"}, "struct type Missing not found"},
+		{[]string{"testdata/records/records.go"}, []string{"Owner", "Owner", "Base"}, ""},
+		{[]string{"testdata/embed/embed.go"}, nil, "Wrapper: embedded type time.Time is from another package, so its fields can't be promoted"},
+		{[]string{"testdata/unresolved/unresolved.go"}, nil, "Broken: embedded type Missing can't be resolved"},
+		{[]string{"testdata/records/records.go", "testdata/embed/embed.go"}, nil, "testdata/embed/embed.go: not in the same directory as testdata/records/records.go"},
+	}
+	for i, test := range tests {
+		_, err := generate(test.files, test.types)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("%d: got error %v, expected %q", i, err, test.err)
+		}
+	}
+}
+
+// TestGeneratedCode builds the code in testdata/records, along with its
+// decoders, and runs the test there, which checks that they decode values the
+// same way as jsonmuncher.Unmarshal() when each key matches a field exactly.
+func TestGeneratedCode(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping build of generated code in short mode")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not found")
+	}
+	// The generated code imports jsonmuncher by its import path, so a copy of
+	// the package is made into a module with that path.
+	tmp := t.TempDir()
+	lib := filepath.Join(tmp, "jsonmuncher")
+	copyGoFiles(t, "../..", lib, false)
+	writeFile(t, filepath.Join(lib, "go.mod"), "module "+importPath+"\n\ngo 1.21\n")
+	rec := filepath.Join(tmp, "records")
+	copyGoFiles(t, "testdata/records", rec, true)
+	writeFile(t, filepath.Join(rec, "go.mod"), "module records\n\ngo 1.21\n\nrequire "+importPath+
+		" v0.0.0\n\nreplace "+importPath+" => ../jsonmuncher\n")
+	cmd := exec.Command(gobin, "test", ".")
+	cmd.Dir = rec
+	cmd.Env = append(os.Environ(), "GO111MODULE=on", "GOFLAGS=-mod=mod", "GOPROXY=off", "GOWORK=off")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, out)
+	}
+}
+
+// copyGoFiles copies the Go files in one directory to another, leaving out
+// tests unless tests is true.
+func copyGoFiles(t *testing.T, from string, to string, tests bool) {
+	err := os.MkdirAll(to, 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err := filepath.Glob(filepath.Join(from, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range names {
+		if !tests && strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+		src, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(to, filepath.Base(name)), string(src))
+	}
+}
+
+// writeFile writes a file, and fails the test if it can't.
+func writeFile(t *testing.T, name string, src string) {
+	err := os.WriteFile(name, []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,55 @@
+// Command jsonmunchergen generates decoders for Go structs, which read them from
+// a jsonmuncher.JsonValue without using reflection. For each struct type, it
+// writes a method:
+//
+//	func (out *T) MunchJSON(v *jsonmuncher.JsonValue) error
+//
+// Usage:
+//
+//	jsonmunchergen [-type T,U] [-o output.go] input.go...
+//
+// The input files must belong to the same package, in the same directory. The
+// rest of the package is loaded as well, so that the fields of structs embedded
+// from its other files can be promoted. By default, a decoder is generated for
+// every struct type declared in the input files, and the output is written next
+// to the first input file, with "_munch.go" in place of ".go". Fields are given
+// keys the same way as by jsonmuncher.Unmarshal(), using their "json" tags, but
+// keys are matched exactly, where Unmarshal() ignores case.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	typeFlag := flag.String("type", "", "comma-separated list of struct types to generate decoders for (default all)")
+	outFlag := flag.String("o", "", "output file (default <first input>_munch.go)")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: jsonmunchergen [-type T,U] [-o output.go] input.go...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	var types []string
+	if *typeFlag != "" {
+		types = strings.Split(*typeFlag, ",")
+	}
+	out := *outFlag
+	if out == "" {
+		out = strings.TrimSuffix(flag.Arg(0), ".go") + "_munch.go"
+	}
+	src, err := generate(flag.Args(), types)
+	if err == nil {
+		err = os.WriteFile(out, src, 0644)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "jsonmunchergen:", err)
+		os.Exit(1)
+	}
+}
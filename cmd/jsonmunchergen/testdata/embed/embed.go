@@ -0,0 +1,7 @@
+package embed
+
+import "time"
+
+type Wrapper struct {
+	time.Time
+}
@@ -0,0 +1,8 @@
+package records
+
+import "time"
+
+type Base struct {
+	ID      int       `json:"id"`
+	Created time.Time `json:"created,omitempty"`
+}
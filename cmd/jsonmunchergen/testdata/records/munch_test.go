@@ -0,0 +1,73 @@
+package records
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/darthfennec/jsonmuncher"
+)
+
+const input = `{
+	"id": 7, "created": "2020-01-02T03:04:05Z", "title": "first \"one\"",
+	"score": "1.5", "small": -8, "count": "65535", "big": 18446744073709551615,
+	"active": true, "tags": ["a", "b"], "unknown": {"x": [1, 2]},
+	"owners": [{"Name": "x", "email": "x@example.com"}, null],
+	"owner": {"Name": "y", "email": null}, "grid": [[1, 2], [], [3]],
+	"attrs": {"k": "v"}, "data": "aGk=", "ratio": 0.25, "Skipped": "no"
+}`
+
+func TestMunchJSON(t *testing.T) {
+	for _, size := range []int{1, 7, 4096} {
+		var got, want Record
+		v, err := jsonmuncher.Parse(iotest.OneByteReader(strings.NewReader(input)), size)
+		if err == nil {
+			err = got.MunchJSON(&v)
+		}
+		if err == nil {
+			err = v.Finish()
+		}
+		if err != nil {
+			t.Fatal(size, err)
+		}
+		v, _ = jsonmuncher.ParseBytes([]byte(input))
+		err = jsonmuncher.Unmarshal(v, &want)
+		if err == nil {
+			err = v.Finish()
+		}
+		if err != nil {
+			t.Fatal(size, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%d: MunchJSON gave %+v, Unmarshal gave %+v", size, got, want)
+		}
+		if got.Base == nil || got.ID != 7 || got.Title != `first "one"` || got.Count != 65535 ||
+			len(got.Owners) != 2 || *got.Owners[0].Email != "x@example.com" || got.Owners[1] != nil ||
+			string(got.Data) != "hi" || got.Skipped != "" {
+			t.Errorf("%d: wrong values %+v", size, got)
+		}
+	}
+	// Unmarshal reads values tagged with ",string" as they are when they aren't
+	// held in a String.
+	var got, want Record
+	v, _ := jsonmuncher.ParseBytes([]byte(`{"score": 2.5, "count": 7}`))
+	err := got.MunchJSON(&v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, _ = jsonmuncher.ParseBytes([]byte(`{"score": 2.5, "count": 7}`))
+	err = jsonmuncher.Unmarshal(v, &want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) || got.Score != 2.5 || got.Count != 7 {
+		t.Errorf("MunchJSON gave %+v, Unmarshal gave %+v", got, want)
+	}
+	var r Record
+	v, _ = jsonmuncher.ParseBytes([]byte(`{"small": 128}`))
+	err = r.MunchJSON(&v)
+	if err == nil {
+		t.Error("out of range value was accepted")
+	}
+}
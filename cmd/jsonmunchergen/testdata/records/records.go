@@ -0,0 +1,29 @@
+package records
+
+type Owner struct {
+	Name  string
+	Email *string `json:"email"`
+}
+
+type Record struct {
+	*Base
+	Title   string            `json:"title"`
+	Score   float64           `json:"score,string"`
+	Small   int8              `json:"small"`
+	Count   uint16            `json:"count,string"`
+	Big     uint64            `json:"big"`
+	Active  bool              `json:"active"`
+	Tags    []string          `json:"tags"`
+	Owners  []*Owner          `json:"owners"`
+	Owner   Owner             `json:"owner"`
+	Grid    [][]int64         `json:"grid"`
+	Attrs   map[string]string `json:"attrs"`
+	Data    []byte            `json:"data"`
+	Ratio   float32           `json:"ratio"`
+	Skipped string            `json:"-"`
+	hidden  int
+}
+
+type Empty struct {
+	hidden int
+}
@@ -0,0 +1,392 @@
+// Code generated by jsonmunchergen. DO NOT EDIT.
+
+package records
+
+import "github.com/darthfennec/jsonmuncher"
+
+// munchKeysOwner holds the keys of Owner, sorted for Compare. They must stay
+// sorted: Compare sorts its arguments in place, and only leaves a sorted slice
+// untouched, which lets concurrent calls share it.
+var munchKeysOwner = []string{"Name", "email"}
+
+// MunchJSON reads the fields of Owner from v. Keys that don't match a field are
+// discarded.
+func (out *Owner) MunchJSON(v *jsonmuncher.JsonValue) error {
+	if v.Type == jsonmuncher.Null {
+		return nil
+	} else if v.Type != jsonmuncher.Object {
+		return jsonmuncher.ErrUnmarshalType{Provided: v.Type, Target: "Owner", Path: v.Path()}
+	}
+	for {
+		key, err := v.NextKey()
+		if err == jsonmuncher.EndOfValue {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		k, ok, err := key.Compare(munchKeysOwner...)
+		if err != nil {
+			return err
+		} else if !ok {
+			// The value is discarded by the next call to NextKey.
+			continue
+		}
+		val, err := v.NextValue()
+		if err != nil {
+			return err
+		}
+		switch k {
+		case "Name":
+			if val.Type != jsonmuncher.Null {
+				out.Name, err = val.ValueString()
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		case "email":
+			if val.Type == jsonmuncher.Null {
+				out.Email = nil
+			} else {
+				if out.Email == nil {
+					out.Email = new(string)
+				}
+				*out.Email, err = val.ValueString()
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// munchKeysRecord holds the keys of Record, sorted for Compare. They must stay
+// sorted: Compare sorts its arguments in place, and only leaves a sorted slice
+// untouched, which lets concurrent calls share it.
+var munchKeysRecord = []string{"active", "attrs", "big", "count", "created", "data", "grid", "id", "owner", "owners", "ratio", "score", "small", "tags", "title"}
+
+// MunchJSON reads the fields of Record from v. Keys that don't match a field are
+// discarded.
+func (out *Record) MunchJSON(v *jsonmuncher.JsonValue) error {
+	if v.Type == jsonmuncher.Null {
+		return nil
+	} else if v.Type != jsonmuncher.Object {
+		return jsonmuncher.ErrUnmarshalType{Provided: v.Type, Target: "Record", Path: v.Path()}
+	}
+	for {
+		key, err := v.NextKey()
+		if err == jsonmuncher.EndOfValue {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		k, ok, err := key.Compare(munchKeysRecord...)
+		if err != nil {
+			return err
+		} else if !ok {
+			// The value is discarded by the next call to NextKey.
+			continue
+		}
+		val, err := v.NextValue()
+		if err != nil {
+			return err
+		}
+		switch k {
+		case "active":
+			if val.Type != jsonmuncher.Null {
+				out.Active, err = val.ValueBool()
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		case "attrs":
+			err = jsonmuncher.Unmarshal(val, &out.Attrs)
+		case "big":
+			if val.Type != jsonmuncher.Null {
+				out.Big, err = val.ValueUint64()
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		case "count":
+			if val.Type == jsonmuncher.String {
+				var s1 string
+				s1, err = val.ValueString()
+				if err == nil {
+					var inner1 jsonmuncher.JsonValue
+					inner1, err = jsonmuncher.ParseBytes([]byte(s1))
+					if err == nil {
+						if inner1.Type != jsonmuncher.Null {
+							var n2 uint64
+							n2, err = inner1.ValueUint64()
+							if err == nil && uint64(uint16(n2)) != n2 {
+								err = jsonmuncher.ErrNumRange{Target: "uint16", Path: inner1.Path()}
+							} else if err == nil {
+								out.Count = uint16(n2)
+							}
+						}
+					}
+					if err == nil {
+						err = inner1.Finish()
+					}
+				}
+			} else {
+				if val.Type != jsonmuncher.Null {
+					var n3 uint64
+					n3, err = val.ValueUint64()
+					if err == nil && uint64(uint16(n3)) != n3 {
+						err = jsonmuncher.ErrNumRange{Target: "uint16", Path: val.Path()}
+					} else if err == nil {
+						out.Count = uint16(n3)
+					}
+				}
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		case "created":
+			if out.Base == nil {
+				out.Base = new(Base)
+			}
+			err = jsonmuncher.Unmarshal(val, &out.Base.Created)
+		case "data":
+			err = jsonmuncher.Unmarshal(val, &out.Data)
+		case "grid":
+			if val.Type == jsonmuncher.Null {
+				out.Grid = nil
+			} else if val.Type != jsonmuncher.Array {
+				err = jsonmuncher.ErrUnmarshalType{Provided: val.Type, Target: "[][]int64", Path: val.Path()}
+			} else {
+				if out.Grid == nil {
+					out.Grid = [][]int64{}
+				} else {
+					out.Grid = out.Grid[:0]
+				}
+				for {
+					var elem4 jsonmuncher.JsonValue
+					elem4, err = val.NextValue()
+					if err == jsonmuncher.EndOfValue {
+						err = nil
+						break
+					} else if err != nil {
+						break
+					}
+					var e4 []int64
+					if elem4.Type == jsonmuncher.Null {
+						e4 = nil
+					} else if elem4.Type != jsonmuncher.Array {
+						err = jsonmuncher.ErrUnmarshalType{Provided: elem4.Type, Target: "[]int64", Path: elem4.Path()}
+					} else {
+						if e4 == nil {
+							e4 = []int64{}
+						} else {
+							e4 = e4[:0]
+						}
+						for {
+							var elem5 jsonmuncher.JsonValue
+							elem5, err = elem4.NextValue()
+							if err == jsonmuncher.EndOfValue {
+								err = nil
+								break
+							} else if err != nil {
+								break
+							}
+							var e5 int64
+							if elem5.Type != jsonmuncher.Null {
+								e5, err = elem5.ValueInt64()
+							}
+							if err == nil {
+								err = elem5.Close()
+							}
+							if err != nil {
+								break
+							}
+							e4 = append(e4, e5)
+						}
+					}
+					if err == nil {
+						err = elem4.Close()
+					}
+					if err != nil {
+						break
+					}
+					out.Grid = append(out.Grid, e4)
+				}
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		case "id":
+			if out.Base == nil {
+				out.Base = new(Base)
+			}
+			if val.Type != jsonmuncher.Null {
+				var n6 int64
+				n6, err = val.ValueInt64()
+				if err == nil && int64(int(n6)) != n6 {
+					err = jsonmuncher.ErrNumRange{Target: "int", Path: val.Path()}
+				} else if err == nil {
+					out.Base.ID = int(n6)
+				}
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		case "owner":
+			err = out.Owner.MunchJSON(&val)
+			if err == nil {
+				err = val.Close()
+			}
+		case "owners":
+			if val.Type == jsonmuncher.Null {
+				out.Owners = nil
+			} else if val.Type != jsonmuncher.Array {
+				err = jsonmuncher.ErrUnmarshalType{Provided: val.Type, Target: "[]*Owner", Path: val.Path()}
+			} else {
+				if out.Owners == nil {
+					out.Owners = []*Owner{}
+				} else {
+					out.Owners = out.Owners[:0]
+				}
+				for {
+					var elem7 jsonmuncher.JsonValue
+					elem7, err = val.NextValue()
+					if err == jsonmuncher.EndOfValue {
+						err = nil
+						break
+					} else if err != nil {
+						break
+					}
+					var e7 *Owner
+					if elem7.Type == jsonmuncher.Null {
+						e7 = nil
+					} else {
+						if e7 == nil {
+							e7 = new(Owner)
+						}
+						err = e7.MunchJSON(&elem7)
+					}
+					if err == nil {
+						err = elem7.Close()
+					}
+					if err != nil {
+						break
+					}
+					out.Owners = append(out.Owners, e7)
+				}
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		case "ratio":
+			err = jsonmuncher.Unmarshal(val, &out.Ratio)
+		case "score":
+			if val.Type == jsonmuncher.String {
+				var s8 string
+				s8, err = val.ValueString()
+				if err == nil {
+					var inner8 jsonmuncher.JsonValue
+					inner8, err = jsonmuncher.ParseBytes([]byte(s8))
+					if err == nil {
+						if inner8.Type != jsonmuncher.Null {
+							out.Score, err = inner8.ValueNum()
+						}
+					}
+					if err == nil {
+						err = inner8.Finish()
+					}
+				}
+			} else {
+				if val.Type != jsonmuncher.Null {
+					out.Score, err = val.ValueNum()
+				}
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		case "small":
+			if val.Type != jsonmuncher.Null {
+				var n9 int64
+				n9, err = val.ValueInt64()
+				if err == nil && int64(int8(n9)) != n9 {
+					err = jsonmuncher.ErrNumRange{Target: "int8", Path: val.Path()}
+				} else if err == nil {
+					out.Small = int8(n9)
+				}
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		case "tags":
+			if val.Type == jsonmuncher.Null {
+				out.Tags = nil
+			} else if val.Type != jsonmuncher.Array {
+				err = jsonmuncher.ErrUnmarshalType{Provided: val.Type, Target: "[]string", Path: val.Path()}
+			} else {
+				if out.Tags == nil {
+					out.Tags = []string{}
+				} else {
+					out.Tags = out.Tags[:0]
+				}
+				for {
+					var elem10 jsonmuncher.JsonValue
+					elem10, err = val.NextValue()
+					if err == jsonmuncher.EndOfValue {
+						err = nil
+						break
+					} else if err != nil {
+						break
+					}
+					var e10 string
+					if elem10.Type != jsonmuncher.Null {
+						e10, err = elem10.ValueString()
+					}
+					if err == nil {
+						err = elem10.Close()
+					}
+					if err != nil {
+						break
+					}
+					out.Tags = append(out.Tags, e10)
+				}
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		case "title":
+			if val.Type != jsonmuncher.Null {
+				out.Title, err = val.ValueString()
+			}
+			if err == nil {
+				err = val.Close()
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// MunchJSON reads the fields of Empty from v. Keys that don't match a field are
+// discarded.
+func (out *Empty) MunchJSON(v *jsonmuncher.JsonValue) error {
+	if v.Type == jsonmuncher.Null {
+		return nil
+	} else if v.Type != jsonmuncher.Object {
+		return jsonmuncher.ErrUnmarshalType{Provided: v.Type, Target: "Empty", Path: v.Path()}
+	}
+	for {
+		key, err := v.NextKey()
+		if err == jsonmuncher.EndOfValue {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		err = key.Close()
+		if err != nil {
+			return err
+		}
+	}
+}
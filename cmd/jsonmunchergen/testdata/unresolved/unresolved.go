@@ -0,0 +1,5 @@
+package unresolved
+
+type Broken struct {
+	Missing
+}
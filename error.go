@@ -12,7 +12,8 @@ import (
 var EndOfValue = errors.New("End of value reached")
 
 // ErrIncomplete is returned when a call is made to a value with an "Incomplete"
-// status, meaning a read error had occurred during a previous operation.
+// status, meaning a read error had occurred during a previous operation. If
+// paths are tracked, it is wrapped in an ErrWithPath.
 var ErrIncomplete = errors.New("Status incomplete denotes failed read")
 
 // ErrWorkingChild is returned when NextKey(), NextValue(), or Close() is
@@ -25,12 +26,51 @@ var ErrWorkingChild = errors.New("Unable to consume when child element is partia
 // argument.
 var ErrNoParamsSpecified = errors.New("At least one argument should be provided")
 
+// ErrNotTopLevel is returned when Finish() is called on a JsonValue that isn't
+// the top-level value of the stream.
+var ErrNotTopLevel = errors.New("Method can only be called on the top-level value")
+
+// ErrInputTooLarge is returned by ParseBytes() and ResetBytes() when the input
+// is 4 GiB or more in length, which is too large to be addressed by the read
+// buffer.
+var ErrInputTooLarge = errors.New("Input must be less than 4 GiB in length")
+
+// ErrLiteralDiscarded is returned by ValueNumRaw() when the literal text of a
+// Number is no longer available, because another Number has since been read. If
+// paths are tracked, it is wrapped in an ErrWithPath.
+var ErrLiteralDiscarded = errors.New("Literal was discarded when the next number was read")
+
+// ErrPartiallyRead is returned by WriteRawTo() when part of the value has
+// already been read, so its text can no longer be reproduced in full.
+var ErrPartiallyRead = errors.New("Unable to copy raw text of a partially read value")
+
+// ErrNotSeekable is returned by Mark() when the stream can't be rewound, because
+// it isn't an io.Seeker.
+var ErrNotSeekable = errors.New("Unable to mark value when stream is not seekable")
+
+// ErrBadIndex is returned by ReadIndex() when the data isn't a valid Index.
+var ErrBadIndex = errors.New("Invalid index data")
+
+// ErrNotIndexed is returned by Indexed.Element() and Indexed.Member() when the
+// top-level value wasn't indexed, because it isn't an Object or Array, or the
+// Index was built with a depth of 0.
+var ErrNotIndexed = errors.New("Value is not indexed")
+
+// ErrInvalidTarget is returned by Unmarshal() when dst isn't a non-nil pointer.
+var ErrInvalidTarget = errors.New("Unmarshal target must be a non-nil pointer")
+
+// ErrInvalidPointer is returned by Seek() when the given string isn't a valid
+// JSON pointer.
+var ErrInvalidPointer = errors.New("Invalid JSON pointer")
+
 // ErrTypeMismatch is returned when a JsonValue method specific to a particular
 // JSON type is called on a different JSON type. For example, ValueNum() will
-// return this error if called on any JsonValue that isn't a Number.
+// return this error if called on any JsonValue that isn't a Number. If paths
+// are tracked (see ParseWithPath), Path holds the path to the value.
 type ErrTypeMismatch struct {
 	Provided JsonType
 	Expected []JsonType
+	Path     string
 }
 
 var showType = [...]string{
@@ -43,7 +83,7 @@ var showType = [...]string{
 }
 
 func newErrTypeMismatch(p JsonType, e ...JsonType) ErrTypeMismatch {
-	return ErrTypeMismatch{p, e}
+	return ErrTypeMismatch{p, e, ""}
 }
 
 // Error implements error for ErrTypeMismatch.
@@ -58,25 +98,197 @@ func (e ErrTypeMismatch) Error() string {
 		}
 		bld.WriteString(showType[e.Expected[i]])
 	}
+	writePath(&bld, e.Path)
+	return bld.String()
+}
+
+// ErrUnmarshalType is returned by Unmarshal() when a JSON value can't be stored
+// in a Go value of the target type, such as a String in an int. If paths are
+// tracked (see ParseWithPath), Path holds the path to the value.
+type ErrUnmarshalType struct {
+	Provided JsonType
+	Target   string
+	Path     string
+}
+
+// Error implements error for ErrUnmarshalType.
+func (e ErrUnmarshalType) Error() string {
+	var bld strings.Builder
+	bld.WriteString("Cannot unmarshal ")
+	bld.WriteString(showType[e.Provided])
+	bld.WriteString(" into Go value of type ")
+	bld.WriteString(e.Target)
+	writePath(&bld, e.Path)
 	return bld.String()
 }
 
+// ErrNumRange is returned when a Number can't be represented by the requested
+// type, either because it is out of range, or because its literal has a
+// fraction or an exponent and the requested type is an integer. If paths are
+// tracked (see ParseWithPath), Path holds the path to the Number.
+type ErrNumRange struct {
+	Target   string
+	Fraction bool
+	Path     string
+}
+
+// Error implements error for ErrNumRange.
+func (e ErrNumRange) Error() string {
+	var bld strings.Builder
+	if e.Fraction {
+		bld.WriteString("Number is not an integer, cannot convert to ")
+	} else {
+		bld.WriteString("Number is out of range for ")
+	}
+	bld.WriteString(e.Target)
+	writePath(&bld, e.Path)
+	return bld.String()
+}
+
+// ErrWithPath adds the path to a value to one of the errors above, such as
+// ErrIncomplete, when paths are tracked (see ParseWithPath). Use errors.Is to
+// check for the wrapped error.
+type ErrWithPath struct {
+	Err  error
+	Path string
+}
+
+// Error implements error for ErrWithPath.
+func (e ErrWithPath) Error() string {
+	var bld strings.Builder
+	bld.WriteString(e.Err.Error())
+	writePath(&bld, e.Path)
+	return bld.String()
+}
+
+// Unwrap returns the wrapped error.
+func (e ErrWithPath) Unwrap() error {
+	return e.Err
+}
+
+// ErrInvalidPath is returned by CompilePath() when an expression isn't valid,
+// or uses a part of JSONPath that isn't supported. Offset is the position in the
+// expression at which the problem was found.
+type ErrInvalidPath struct {
+	Expr   string
+	Offset int
+	Msg    string
+}
+
+// Error implements error for ErrInvalidPath.
+func (e ErrInvalidPath) Error() string {
+	return "Invalid JSONPath " + strconv.Quote(e.Expr) + " at offset " +
+		strconv.Itoa(e.Offset) + ": " + e.Msg
+}
+
+// limitMsg builds the message for an error returned when a limit is exceeded.
+func limitMsg(what string, limit uint64, unit string, offs uint64, path string) string {
+	var bld strings.Builder
+	bld.WriteString(what)
+	bld.WriteString(" exceeds limit of ")
+	bld.WriteString(strconv.FormatUint(limit, 10))
+	bld.WriteString(unit)
+	bld.WriteString(" at file offset ")
+	bld.WriteString(strconv.FormatUint(offs, 10))
+	writePath(&bld, path)
+	return bld.String()
+}
+
+// writePath adds the path to an error message, if there is one.
+func writePath(bld *strings.Builder, path string) {
+	if path != "" {
+		bld.WriteString(" (path ")
+		bld.WriteString(path)
+		bld.WriteString(")")
+	}
+}
+
+// ErrDepthLimit is returned when an Object or Array is nested more deeply than
+// Limits.MaxDepth allows. Offset is the file offset of the Object or Array that
+// exceeded the limit.
+type ErrDepthLimit struct {
+	Offset uint64
+	Limit  uint64
+	Path   string
+}
+
+// Error implements error for ErrDepthLimit.
+func (e ErrDepthLimit) Error() string {
+	return limitMsg("Nesting depth", e.Limit, "", e.Offset, e.Path)
+}
+
+// ErrStringLimit is returned when a String or key is longer than
+// Limits.MaxStringBytes allows. Offset is the file offset of the String.
+type ErrStringLimit struct {
+	Offset uint64
+	Limit  uint64
+	Path   string
+}
+
+// Error implements error for ErrStringLimit.
+func (e ErrStringLimit) Error() string {
+	return limitMsg("String length", e.Limit, " bytes", e.Offset, e.Path)
+}
+
+// ErrNumberLimit is returned when a Number has more digits than
+// Limits.MaxNumberDigits allows. Offset is the file offset of the Number.
+type ErrNumberLimit struct {
+	Offset uint64
+	Limit  uint64
+	Path   string
+}
+
+// Error implements error for ErrNumberLimit.
+func (e ErrNumberLimit) Error() string {
+	return limitMsg("Number length", e.Limit, " digits", e.Offset, e.Path)
+}
+
+// ErrTotalLimit is returned when the stream is longer than Limits.MaxTotalBytes
+// allows. Offset is the file offset of the first byte past the limit.
+type ErrTotalLimit struct {
+	Offset uint64
+	Limit  uint64
+	Path   string
+}
+
+// Error implements error for ErrTotalLimit.
+func (e ErrTotalLimit) Error() string {
+	return limitMsg("Input length", e.Limit, " bytes", e.Offset, e.Path)
+}
+
+// ErrMemberLimit is returned when an Object has more keys than
+// Limits.MaxMembers allows. Offset is the file offset of the first key past the
+// limit.
+type ErrMemberLimit struct {
+	Offset uint64
+	Limit  uint64
+	Path   string
+}
+
+// Error implements error for ErrMemberLimit.
+func (e ErrMemberLimit) Error() string {
+	return limitMsg("Object size", e.Limit, " members", e.Offset, e.Path)
+}
+
 // ErrUnexpectedChar is returned whenever a syntactic parse error is
-// encountered: an illegal character or an unexpected EOF.
+// encountered: an illegal character or an unexpected EOF. If paths are tracked
+// (see ParseWithPath), Path holds the path to the value where the error was
+// found.
 type ErrUnexpectedChar struct {
 	Offset      uint64
 	ProvidedEOF bool
 	Provided    byte
 	Expected    []byte
 	CustomMsg   string
+	Path        string
 }
 
 func newErrUnexpectedChar(off uint64, p byte, e ...byte) ErrUnexpectedChar {
-	return ErrUnexpectedChar{off, false, p, e, ""}
+	return ErrUnexpectedChar{off, false, p, e, "", ""}
 }
 
 func newErrUnexpectedEOF(off uint64, e ...byte) ErrUnexpectedChar {
-	return ErrUnexpectedChar{off, true, 0, e, ""}
+	return ErrUnexpectedChar{off, true, 0, e, "", ""}
 }
 
 // Error implements error for ErrUnexpectedChar.
@@ -90,6 +302,7 @@ func (e ErrUnexpectedChar) Error() string {
 	}
 	bld.WriteString(" at file offset ")
 	bld.WriteString(strconv.FormatUint(e.Offset, 10))
+	writePath(&bld, e.Path)
 	if e.CustomMsg != "" {
 		bld.WriteString(": ")
 		bld.WriteString(e.CustomMsg)
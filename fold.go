@@ -0,0 +1,183 @@
+package jsonmuncher
+
+import (
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// foldRune returns the canonical form of a rune under simple case folding,
+// which is the smallest rune that folds to the same set. Two runes are equal
+// under folding if their canonical forms are equal.
+func foldRune(r rune) rune {
+	if r < utf8.RuneSelf {
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		return r
+	}
+	min := r
+	for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+		if f < min {
+			min = f
+		}
+	}
+	return min
+}
+
+// foldLess reports whether a sorts before b, when both are case folded.
+func foldLess(a string, b string) bool {
+	for a != "" && b != "" {
+		ra, la := utf8.DecodeRuneInString(a)
+		rb, lb := utf8.DecodeRuneInString(b)
+		ra, rb = foldRune(ra), foldRune(rb)
+		if ra != rb {
+			return ra < rb
+		}
+		a, b = a[la:], b[lb:]
+	}
+	return b != ""
+}
+
+// foldPrefix checks whether s begins with a string equal to prefix under case
+// folding. If it does, the length of that part of s is returned.
+func foldPrefix(s string, prefix string) (int, bool) {
+	n := 0
+	for prefix != "" {
+		if n >= len(s) {
+			return 0, false
+		}
+		rp, lp := utf8.DecodeRuneInString(prefix)
+		rs, ls := utf8.DecodeRuneInString(s[n:])
+		if foldRune(rp) != foldRune(rs) {
+			return 0, false
+		}
+		prefix = prefix[lp:]
+		n += ls
+	}
+	return n, true
+}
+
+// foldSort sorts the inputs in-place, in case folded order. Like simpleSort,
+// this is an insertion sort.
+func foldSort(vals []string) {
+	for i := 1; i < len(vals); i++ {
+		for j := i; j > 0 && foldLess(vals[j], vals[j-1]); j-- {
+			vals[j], vals[j-1] = vals[j-1], vals[j]
+		}
+	}
+}
+
+// compareFoldRead is like compareRead, but compares the strings under case
+// folding. The strings must be sorted by foldSort for this to work properly.
+// Since equal runes can be encoded with different lengths, the String is
+// decoded one rune at a time, and the position in each string is tracked
+// separately.
+func compareFoldRead(data *JsonValue, vals []string) (string, bool, error) {
+	var buf [16]byte
+	x, off, n := 0, 0, 0
+	for {
+		l, err := data.Read(buf[n:])
+		if err != nil && err != io.EOF {
+			return "", false, err
+		}
+		n += l
+		i := 0
+		for i < n && (err == io.EOF || utf8.FullRune(buf[i:n])) {
+			r, size := utf8.DecodeRune(buf[i:n])
+			i += size
+			for {
+				if off < len(vals[x]) {
+					c, size := utf8.DecodeRuneInString(vals[x][off:])
+					if foldRune(c) == foldRune(r) {
+						off += size
+						break
+					}
+				}
+				if x+1 >= len(vals) {
+					return "", false, data.Close()
+				}
+				next, ok := foldPrefix(vals[x+1], vals[x][:off])
+				if !ok {
+					return "", false, data.Close()
+				}
+				x, off = x+1, next
+			}
+		}
+		// Keep any incomplete rune for the next read.
+		n = copy(buf[:], buf[i:n])
+		if err == io.EOF && off == len(vals[x]) {
+			return vals[x], true, nil
+		} else if err == io.EOF {
+			return "", false, nil
+		}
+	}
+}
+
+// prefixRead reads a String value, and checks whether it begins with any of the
+// given strings. The strings must be sorted for this to work properly. Once a
+// match is found, the rest of the String is discarded.
+func prefixRead(data *JsonValue, vals []string) (string, bool, error) {
+	var buf [16]byte
+	x, y := 0, 0
+	for {
+		if y == len(vals[x]) {
+			return vals[x], true, data.Close()
+		}
+		l, err := data.Read(buf[:])
+		if err != nil && err != io.EOF {
+			return "", false, err
+		}
+		for i := 0; i < l; i++ {
+			for vals[x][y] != buf[i] {
+				if x+1 >= len(vals) || !strings.HasPrefix(vals[x+1], vals[x][:y]) {
+					return "", false, data.Close()
+				}
+				x++
+			}
+			y++
+			if y == len(vals[x]) {
+				return vals[x], true, data.Close()
+			}
+		}
+		if err == io.EOF {
+			return "", false, nil
+		}
+	}
+}
+
+// CompareFold is like Compare, but the String value matches an argument if they
+// are equal under simple Unicode case folding, as with strings.EqualFold. This
+// is how encoding/json matches keys to struct fields. The matched argument is
+// returned, rather than the String value itself.
+func (data *JsonValue) CompareFold(vals ...string) (string, bool, error) {
+	if len(vals) <= 0 {
+		return "", false, ErrNoParamsSpecified
+	}
+	foldSort(vals)
+	return compareFoldRead(data, vals)
+}
+
+// FindKeyFold is like FindKey, but a key matches an argument if they are equal
+// under simple Unicode case folding, as with CompareFold.
+func (data *JsonValue) FindKeyFold(keys ...string) (string, JsonValue, bool, error) {
+	if len(keys) <= 0 {
+		return "", JsonValue{}, false, ErrNoParamsSpecified
+	}
+	foldSort(keys)
+	return findKey(data, keys, true)
+}
+
+// HasPrefix is a helper function, designed to read a String value and check
+// whether it begins with one of the provided arguments. If the String value
+// begins with none of the arguments, false is returned. Otherwise, true is
+// returned along with the matched argument; if several match, the shortest is
+// returned. The String value is consumed in the process.
+func (data *JsonValue) HasPrefix(prefixes ...string) (string, bool, error) {
+	if len(prefixes) <= 0 {
+		return "", false, ErrNoParamsSpecified
+	}
+	simpleSort(prefixes)
+	return prefixRead(data, prefixes)
+}
@@ -0,0 +1,103 @@
+package jsonmuncher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompareFold(t *testing.T) {
+	json := "[\"USERNAME\", \"\\u212Aelvin\", \"\\u00c9T\\u00c9\", \"\u03a3\u0391\u03a3\", \"user\", \"usernames\", \"\"]"
+	vals := []string{"ΣΑΣ", "UserName", "été", "kelvin", "ΣΑΣ", "user"}
+	exp := []string{"UserName", "kelvin", "été", "σας", "user", "", ""}
+	for size := 1; size <= len(json); size++ {
+		v1, _ := Parse(strings.NewReader(json), size)
+		for i := 0; ; i++ {
+			v2, e := v1.NextValue()
+			if e == EndOfValue {
+				assert(t, i != len(exp),
+					"1", size, i)
+				break
+			}
+			sk, mk, ek := v2.CompareFold("σας", "UserName", "été", "kelvin", "user")
+			assert(t, sk != exp[i] || mk != (exp[i] != "") || ek != nil,
+				"2", size, i, sk, mk, ek)
+		}
+		e := v1.Close()
+		assert(t, e != nil,
+			"3", size, e)
+	}
+	v1, _ := Parse(strings.NewReader("\"ſ\""), 4)
+	sk, mk, ek := v1.CompareFold(vals...)
+	assert(t, sk != "" || mk || ek != nil,
+		"4", sk, mk, ek)
+	v1, _ = Parse(strings.NewReader("\"uſer\""), 4)
+	sk, mk, ek = v1.CompareFold(vals...)
+	assert(t, sk != "user" || !mk || ek != nil,
+		"5", sk, mk, ek)
+	v1, _ = Parse(strings.NewReader("\"x\""), 4)
+	sk, mk, ek = v1.CompareFold()
+	assert(t, sk != "" || mk || ek != ErrNoParamsSpecified,
+		"6", sk, mk, ek)
+	json = "\"Username\""
+	r := strings.NewReader(json)
+	p := NewParser(r, 16)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(json)
+		p.Reset(r)
+		v1, _ := p.Next()
+		v1.CompareFold(vals...)
+	})
+	assert(t, allocs != 0,
+		"7", allocs)
+}
+
+func TestFindKeyFold(t *testing.T) {
+	json := "{\"ID\":1,\"UserName\":2,\"user_name\":3,\"USERNAME\":4}"
+	for size := 1; size <= len(json); size++ {
+		v1, _ := Parse(strings.NewReader(json), size)
+		sk, vk, mk, ek := v1.FindKeyFold("username", "name")
+		assert(t, sk != "username" || !mk || ek != nil,
+			"1", size, sk, mk, ek)
+		vn, _ := vk.ValueNum()
+		assert(t, vn != 2,
+			"2", size, vn)
+		sk, vk, mk, ek = v1.FindKeyFold("username")
+		vn, _ = vk.ValueNum()
+		assert(t, sk != "username" || !mk || ek != nil || vn != 4,
+			"3", size, sk, mk, ek, vn)
+		sk, _, mk, ek = v1.FindKeyFold("id")
+		assert(t, sk != "" || mk || ek != nil,
+			"4", size, sk, mk, ek)
+	}
+}
+
+func TestHasPrefix(t *testing.T) {
+	json := "[\"https://example.com\", \"http://example.com\", \"ftp://x\", \"ht\", \"\"]"
+	exp := []string{"https://", "http://", "", "", ""}
+	for size := 1; size <= len(json); size++ {
+		v1, _ := Parse(strings.NewReader(json), size)
+		for i := 0; ; i++ {
+			v2, e := v1.NextValue()
+			if e == EndOfValue {
+				assert(t, i != len(exp),
+					"1", size, i)
+				break
+			}
+			sk, mk, ek := v2.HasPrefix("https://", "http://", "mailto:")
+			assert(t, sk != exp[i] || mk != (exp[i] != "") || ek != nil,
+				"2", size, i, sk, mk, ek)
+		}
+	}
+	v1, _ := Parse(strings.NewReader("\"abc\""), 4)
+	sk, mk, ek := v1.HasPrefix("abcd", "ab", "a")
+	assert(t, sk != "a" || !mk || ek != nil || v1.Status != Complete,
+		"3", sk, mk, ek, v1.Status)
+	v1, _ = Parse(strings.NewReader("\"abc\""), 4)
+	sk, mk, ek = v1.HasPrefix("")
+	assert(t, sk != "" || !mk || ek != nil || v1.Status != Complete,
+		"4", sk, mk, ek, v1.Status)
+	v1, _ = Parse(strings.NewReader("[1]"), 4)
+	_, _, ek = v1.HasPrefix("a")
+	assert(t, ek == nil,
+		"5", ek)
+}
@@ -0,0 +1,373 @@
+package jsonmuncher
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"strings"
+)
+
+// indexMagic identifies the encoded form of an Index, and its version.
+const indexMagic = "JMI\x01"
+
+// Index records where the elements of Arrays and the members of Objects begin
+// and end in a JSON file, down to a chosen depth, so that they can be read
+// without parsing everything before them. An Index is built by BuildIndex(),
+// can be saved with WriteTo() and loaded with ReadIndex(), and is used through
+// OpenIndexed().
+type Index struct {
+	// start and end are the file offsets of the top-level value.
+	start uint64
+	end   uint64
+	// root is one more than the position of the top-level value in nodes, or 0
+	// if it wasn't indexed.
+	root  uint32
+	nodes []indexNode
+}
+
+// indexNode is an indexed Object or Array.
+type indexNode struct {
+	typ     JsonType
+	entries []indexEntry
+}
+
+// indexEntry is an element of an indexed Array, or a member of an indexed
+// Object. The key is decoded, and start and end are the file offsets of the
+// value. node is one more than the position of the value in nodes, or 0 if it
+// wasn't indexed.
+type indexEntry struct {
+	key   string
+	start uint64
+	end   uint64
+	node  uint32
+}
+
+// BuildIndex reads a JSON file from r in a single pass, using a read buffer of
+// the given size, and records the offsets of the elements and members of every
+// Array and Object less than depth levels below the top-level value. With a
+// depth of 1, only the contents of the top-level value are indexed. Values
+// below the indexed depth are skipped with Close(), so their syntax is only
+// loosely checked.
+func BuildIndex(r io.Reader, size int, depth int) (*Index, error) {
+	val, err := Parse(r, size)
+	if err != nil {
+		return nil, err
+	}
+	idx := &Index{start: val.offs}
+	idx.root, err = indexValue(idx, &val, depth)
+	if err != nil {
+		return nil, err
+	}
+	idx.end = endOffs(val.buffer)
+	return idx, nil
+}
+
+// indexValue reads a value, adding it to the index if it is an Object or Array
+// within the given depth, and returns its position in the index.
+func indexValue(idx *Index, val *JsonValue, depth int) (uint32, error) {
+	if depth <= 0 || val.Type != Object && val.Type != Array {
+		return 0, val.Close()
+	}
+	idx.nodes = append(idx.nodes, indexNode{typ: val.Type})
+	n := uint32(len(idx.nodes))
+	for {
+		var ent indexEntry
+		if val.Type == Object {
+			key, err := val.NextKey()
+			if err == EndOfValue {
+				break
+			} else if err != nil {
+				return 0, err
+			}
+			ent.key, err = readText(&key)
+			if err != nil {
+				return 0, err
+			}
+		}
+		elem, err := val.NextValue()
+		if err == EndOfValue {
+			break
+		} else if err != nil {
+			return 0, err
+		}
+		ent.start = elem.offs
+		ent.node, err = indexValue(idx, &elem, depth-1)
+		if err != nil {
+			return 0, err
+		}
+		ent.end = endOffs(val.buffer)
+		idx.nodes[n-1].entries = append(idx.nodes[n-1].entries, ent)
+	}
+	return n, nil
+}
+
+// readText reads the whole of a String or key into a string.
+func readText(data *JsonValue) (string, error) {
+	var buf [64]byte
+	var bld strings.Builder
+	for {
+		l, err := data.Read(buf[:])
+		bld.Write(buf[:l])
+		if err == io.EOF {
+			return bld.String(), nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+}
+
+// endOffs returns the file offset of the end of the value that has just been
+// read, which is that of the lookahead byte, or of the end of the stream.
+func endOffs(buf *buffer) uint64 {
+	if buf.err == io.EOF {
+		return buf.foffs - uint64(len(buf.data)) + uint64(buf.erroffs)
+	}
+	return foffs(buf)
+}
+
+// WriteTo writes the Index to w in a compact binary form, which can be read back
+// with ReadIndex(). It implements io.WriterTo.
+func (idx *Index) WriteTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	var tmp [binary.MaxVarintLen64]byte
+	var n int64
+	put := func(b []byte) {
+		l, _ := bw.Write(b)
+		n += int64(l)
+	}
+	putUint := func(x uint64) {
+		put(tmp[:binary.PutUvarint(tmp[:], x)])
+	}
+	put([]byte(indexMagic))
+	putUint(idx.start)
+	putUint(idx.end)
+	putUint(uint64(idx.root))
+	putUint(uint64(len(idx.nodes)))
+	for i := range idx.nodes {
+		node := &idx.nodes[i]
+		put([]byte{byte(node.typ)})
+		putUint(uint64(len(node.entries)))
+		// Offsets are written relative to the end of the previous entry, which
+		// keeps them short.
+		var prev uint64
+		for j := range node.entries {
+			ent := &node.entries[j]
+			if node.typ == Object {
+				putUint(uint64(len(ent.key)))
+				put([]byte(ent.key))
+			}
+			putUint(ent.start - prev)
+			putUint(ent.end - ent.start)
+			putUint(uint64(ent.node))
+			prev = ent.end
+		}
+	}
+	return n, bw.Flush()
+}
+
+// ReadIndex reads an Index written by WriteTo(). If r isn't an io.ByteReader, it
+// is buffered, so more of it may be consumed than the Index takes up. If the
+// data isn't a valid Index, ErrBadIndex is returned.
+func ReadIndex(r io.Reader) (*Index, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+	var err error
+	getUint := func() uint64 {
+		if err != nil {
+			return 0
+		}
+		var x uint64
+		x, err = binary.ReadUvarint(br)
+		return x
+	}
+	getByte := func() byte {
+		if err != nil {
+			return 0
+		}
+		var c byte
+		c, err = br.ReadByte()
+		return c
+	}
+	for i := 0; i < len(indexMagic) && err == nil; i++ {
+		if getByte() != indexMagic[i] && err == nil {
+			return nil, ErrBadIndex
+		}
+	}
+	idx := &Index{start: getUint(), end: getUint()}
+	root := getUint()
+	count := getUint()
+	if err == nil && (root > count || count > 1<<32-1) {
+		return nil, ErrBadIndex
+	}
+	for i := uint64(0); i < count && err == nil; i++ {
+		node := indexNode{typ: JsonType(getByte())}
+		if err == nil && node.typ != Object && node.typ != Array {
+			return nil, ErrBadIndex
+		}
+		entries := getUint()
+		var prev uint64
+		for j := uint64(0); j < entries && err == nil; j++ {
+			var ent indexEntry
+			if node.typ == Object {
+				var key []byte
+				for k := getUint(); k > 0 && err == nil; k-- {
+					key = append(key, getByte())
+				}
+				ent.key = string(key)
+			}
+			ent.start = prev + getUint()
+			ent.end = ent.start + getUint()
+			next := getUint()
+			if err == nil && next > count {
+				return nil, ErrBadIndex
+			}
+			ent.node = uint32(next)
+			node.entries = append(node.entries, ent)
+			prev = ent.end
+		}
+		idx.nodes = append(idx.nodes, node)
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return nil, ErrBadIndex
+	} else if err != nil {
+		return nil, err
+	}
+	idx.root = uint32(root)
+	return idx, nil
+}
+
+// Indexed reads values straight out of a JSON file, using an Index to find
+// them. Each value it returns is parsed with its own read buffer, so values can
+// be read in any order, and from several goroutines at once.
+type Indexed struct {
+	// BufferSize is the size of the read buffer allocated for each value. If it
+	// is 0, a size of 4096 is used.
+	BufferSize int
+	r          io.ReaderAt
+	idx        *Index
+}
+
+// OpenIndexed prepares to read values from r, which must hold the same file
+// that the Index was built from.
+func OpenIndexed(r io.ReaderAt, idx *Index) *Indexed {
+	return &Indexed{r: r, idx: idx}
+}
+
+// open begins to parse the value between the given file offsets. Offsets within
+// the value, such as those in errors, are relative to the start of the file.
+func (x *Indexed) open(start uint64, end uint64) (JsonValue, error) {
+	size := x.BufferSize
+	if size == 0 {
+		size = 4096
+	}
+	buf := &buffer{data: make([]byte, size), offs: uint32(size), foffs: start,
+		stream: io.NewSectionReader(x.r, int64(start), int64(end-start))}
+	_ = feedq(buf) && feed(buf)
+	next(buf)
+	return readValue(buf)
+}
+
+// Element returns the element at the given position in the top-level Array. If
+// there is no such element, false is returned.
+func (x *Indexed) Element(n int) (JsonValue, bool, error) {
+	if n < 0 {
+		return JsonValue{}, false, nil
+	}
+	return x.lookup(x.idx.root, Array, "", uint64(n))
+}
+
+// Member returns the value associated with the given key in the top-level
+// Object. If the key appears more than once, the first is used. If there is no
+// such key, false is returned.
+func (x *Indexed) Member(key string) (JsonValue, bool, error) {
+	return x.lookup(x.idx.root, Object, key, 0)
+}
+
+// lookup finds a member or element of an indexed value, and begins to parse it.
+func (x *Indexed) lookup(n uint32, typ JsonType, key string, i uint64) (JsonValue, bool, error) {
+	if n == 0 {
+		return JsonValue{}, false, ErrNotIndexed
+	}
+	node := &x.idx.nodes[n-1]
+	if node.typ != typ {
+		return JsonValue{}, false, newErrTypeMismatch(node.typ, typ)
+	}
+	if typ == Array {
+		if i >= uint64(len(node.entries)) {
+			return JsonValue{}, false, nil
+		}
+		ent := &node.entries[i]
+		val, err := x.open(ent.start, ent.end)
+		return val, err == nil, err
+	}
+	for j := range node.entries {
+		if ent := &node.entries[j]; ent.key == key {
+			val, err := x.open(ent.start, ent.end)
+			return val, err == nil, err
+		}
+	}
+	return JsonValue{}, false, nil
+}
+
+// Seek finds a value by its JSON pointer, like JsonValue.Seek(). The Index is
+// followed as deep as it goes, and from there, the value is found by parsing as
+// usual. If the value isn't found, false is returned.
+func (x *Indexed) Seek(pointer string) (JsonValue, bool, error) {
+	err := checkPointer(pointer)
+	if err != nil {
+		return JsonValue{}, false, err
+	}
+	n, start, end := x.idx.root, x.idx.start, x.idx.end
+	for n != 0 && pointer != "" {
+		tok := pointer[1:]
+		rest := ""
+		if i := strings.IndexByte(tok, '/'); i >= 0 {
+			tok, rest = tok[:i], tok[i:]
+		}
+		node := &x.idx.nodes[n-1]
+		var ent *indexEntry
+		if node.typ == Array {
+			if i, ok := indexToken(tok); ok && i < uint64(len(node.entries)) {
+				ent = &node.entries[i]
+			}
+		} else {
+			for j := range node.entries {
+				if matchKey(node.entries[j].key, tok) {
+					ent = &node.entries[j]
+					break
+				}
+			}
+		}
+		if ent == nil {
+			return JsonValue{}, false, nil
+		}
+		n, start, end, pointer = ent.node, ent.start, ent.end, rest
+	}
+	val, err := x.open(start, end)
+	if err != nil {
+		return JsonValue{}, false, err
+	} else if pointer == "" {
+		return val, true, nil
+	}
+	return val.Seek(pointer)
+}
+
+// matchKey compares a decoded key against an escaped reference token from a
+// JSON pointer.
+func matchKey(key string, tok string) bool {
+	j := 0
+	for i := 0; i < len(tok); i++ {
+		c := tok[i]
+		if c == '~' {
+			i++
+			c = "~/"[tok[i]-'0']
+		}
+		if j >= len(key) || key[j] != c {
+			return false
+		}
+		j++
+	}
+	return j == len(key)
+}
@@ -0,0 +1,148 @@
+package jsonmuncher
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+const indexJson = ` {"users": [{"id": 1, "name": "a"}, {"id": 2, "name": "b\"}"}],
+	"a\/b": true, "m~n": null, "count": 2, "tags": ["x", "y"]} `
+
+func indexString(t *testing.T, val JsonValue, err error) string {
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bld strings.Builder
+	_, err = val.WriteRawTo(&bld)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bld.String()
+}
+
+func TestIndex(t *testing.T) {
+	for depth := 0; depth < 4; depth++ {
+		for size := 1; size <= len(indexJson); size++ {
+			idx, err := BuildIndex(strings.NewReader(indexJson), size, depth)
+			assert(t, err != nil,
+				"1", depth, size, err)
+			x := OpenIndexed(strings.NewReader(indexJson), idx)
+			x.BufferSize = size
+			tests := []struct {
+				ptr string
+				val string
+			}{
+				{"/users/1/name", `"b\"}"`},
+				{"/users/0", `{"id": 1, "name": "a"}`},
+				{"/a~1b", "true"},
+				{"/m~0n", "null"},
+				{"/count", "2"},
+				{"/tags/1", `"y"`},
+			}
+			for _, test := range tests {
+				val, ok, err := x.Seek(test.ptr)
+				assert(t, !ok || indexString(t, val, err) != test.val,
+					"2", depth, size, test.ptr, ok, err)
+			}
+			for _, ptr := range []string{"/users/2", "/nothing", "/tags/-", "/users/01"} {
+				_, ok, err := x.Seek(ptr)
+				assert(t, ok || err != nil,
+					"3", depth, size, ptr, ok, err)
+			}
+			val, ok, err := x.Seek("")
+			assert(t, !ok || val.Type != Object || val.offs != 1,
+				"4", depth, size, ok, err)
+			val, ok, err = x.Member("count")
+			if depth == 0 {
+				assert(t, ok || err != ErrNotIndexed,
+					"5", size, ok, err)
+				continue
+			}
+			assert(t, !ok || indexString(t, val, err) != "2",
+				"6", depth, size, ok, err)
+			_, ok, err = x.Member("missing")
+			assert(t, ok || err != nil,
+				"7", depth, size, ok, err)
+			_, ok, err = x.Element(0)
+			assert(t, ok || err == nil || err.Error() != "Method cannot be called on type Object, only on Array",
+				"8", depth, size, ok, err)
+		}
+	}
+}
+
+func TestIndexArray(t *testing.T) {
+	json := "[1, [2, 3], {\"a\": 4}, \"five\"]"
+	idx, err := BuildIndex(strings.NewReader(json), 8, 1)
+	assert(t, err != nil,
+		"1", err)
+	x := OpenIndexed(strings.NewReader(json), idx)
+	expect := []string{"1", "[2, 3]", `{"a": 4}`, `"five"`}
+	for i := len(expect) - 1; i >= 0; i-- {
+		val, ok, err := x.Element(i)
+		assert(t, !ok || indexString(t, val, err) != expect[i],
+			"2", i, ok, err)
+	}
+	_, ok, err := x.Element(4)
+	assert(t, ok || err != nil,
+		"3", ok, err)
+	_, ok, err = x.Element(-1)
+	assert(t, ok || err != nil,
+		"4", ok, err)
+	val, ok, err := x.Seek("/2/a")
+	assert(t, !ok || indexString(t, val, err) != "4",
+		"5", ok, err)
+	_, ok, err = x.Member("a")
+	assert(t, ok || err == nil || err.Error() != "Method cannot be called on type Array, only on Object",
+		"6", ok, err)
+}
+
+func TestIndexErrors(t *testing.T) {
+	json := "[1, {\"a\": tru}, 3]"
+	idx, err := BuildIndex(strings.NewReader(json), 8, 2)
+	assert(t, err == nil || err.Error() != "Unexpected '}' at file offset 13, expected 'e'",
+		"1", err)
+	idx, err = BuildIndex(strings.NewReader(json), 8, 1)
+	assert(t, err != nil,
+		"2", err)
+	x := OpenIndexed(strings.NewReader(json), idx)
+	val, ok, err := x.Element(1)
+	assert(t, !ok || err != nil,
+		"3", ok, err)
+	_, _, _, err = val.FindKey("a")
+	assert(t, err == nil || err.Error() != "Unexpected '}' at file offset 13, expected 'e'",
+		"4", err)
+	_, err = BuildIndex(strings.NewReader("[1, 2"), 8, 1)
+	assert(t, err == nil,
+		"5", err)
+}
+
+func TestIndexEncoding(t *testing.T) {
+	idx, err := BuildIndex(strings.NewReader(indexJson), 16, 3)
+	assert(t, err != nil,
+		"1", err)
+	var enc bytes.Buffer
+	n, err := idx.WriteTo(&enc)
+	assert(t, err != nil || n != int64(enc.Len()),
+		"2", n, enc.Len(), err)
+	data := enc.Bytes()
+	idx2, err := ReadIndex(bytes.NewReader(data))
+	assert(t, err != nil,
+		"3", err)
+	x := OpenIndexed(strings.NewReader(indexJson), idx2)
+	val, ok, err := x.Seek("/users/1/name")
+	assert(t, !ok || indexString(t, val, err) != `"b\"}"`,
+		"4", ok, err)
+	idx2, err = ReadIndex(io.MultiReader(bytes.NewReader(data)))
+	assert(t, err != nil || len(idx2.nodes) != len(idx.nodes),
+		"5", err)
+	for i := 0; i < len(data); i++ {
+		_, err = ReadIndex(bytes.NewReader(data[:i]))
+		assert(t, err != ErrBadIndex,
+			"6", i, err)
+	}
+	_, err = ReadIndex(strings.NewReader("JMI\x02"))
+	assert(t, err != ErrBadIndex,
+		"7", err)
+}
@@ -0,0 +1,119 @@
+//go:build go1.23
+
+package jsonmuncher
+
+import "iter"
+
+// Member is a key/value pair from an Object, as produced by Members(). Key can
+// be read directly; the value is read by calling Value().
+type Member struct {
+	// Key is the key of this pair.
+	Key JsonValue
+	// obj is a copy of the Object this pair belongs to, used to read the value.
+	// Members copies its state back once the body of the loop is done. Holding
+	// a pointer instead would move the Object to the heap.
+	obj JsonValue
+	// val is the value of this pair, once it has been read.
+	val JsonValue
+}
+
+// Value reads the value of this pair. Any part of the key that hasn't been read
+// yet is discarded. Calling Value more than once returns the same JsonValue.
+func (m *Member) Value() (*JsonValue, error) {
+	if m.val.buffer != nil {
+		return &m.val, nil
+	}
+	err := m.Key.Close()
+	if err != nil {
+		return nil, err
+	}
+	m.val, err = m.obj.NextValue()
+	if err != nil {
+		return nil, err
+	}
+	return &m.val, nil
+}
+
+// Elements returns an iterator over the elements of an Array, for use with a
+// range loop. Each element is closed once the body of the loop is done with it,
+// so it doesn't need to be read in its entirety. If an error occurs, it is
+// produced along with a nil element, and the iteration stops. If the loop is
+// exited early, the rest of the Array is left to be read. An element must not
+// be used after the iteration in which it was produced. An element may be
+// consumed through a copy, such as one passed to Unmarshal() or Walk().
+func (data *JsonValue) Elements() iter.Seq2[*JsonValue, error] {
+	return func(yield func(*JsonValue, error) bool) {
+		if data.Type != Array {
+			yield(nil, newErrWrongType(data, Array))
+			return
+		}
+		var elem JsonValue
+		for {
+			var err error
+			elem, err = data.NextValue()
+			if err == EndOfValue {
+				return
+			} else if err != nil {
+				yield(nil, err)
+				return
+			}
+			more := yield(&elem, nil)
+			if !consumed(data) {
+				err = elem.Close()
+			}
+			if err != nil {
+				if more {
+					yield(nil, err)
+				}
+				return
+			} else if !more {
+				return
+			}
+		}
+	}
+}
+
+// Members returns an iterator over the key/value pairs of an Object, for use
+// with a range loop. The key and value of each pair are closed once the body of
+// the loop is done with them, so neither needs to be read in its entirety. If
+// an error occurs, it is produced along with a nil Member, and the iteration
+// stops. If the loop is exited early, the rest of the Object is left to be read.
+// A Member must not be used after the iteration in which it was produced. The
+// value may be consumed through a copy, such as one passed to Unmarshal() or
+// Walk().
+func (data *JsonValue) Members() iter.Seq2[*Member, error] {
+	return func(yield func(*Member, error) bool) {
+		if data.Type != Object {
+			yield(nil, newErrWrongType(data, Object))
+			return
+		}
+		var m Member
+		for {
+			key, err := data.NextKey()
+			if err == EndOfValue {
+				return
+			} else if err != nil {
+				yield(nil, err)
+				return
+			}
+			m = Member{Key: key, obj: *data}
+			more := yield(&m, nil)
+			if m.val.buffer != nil || m.obj.Status != Working {
+				data.Status = m.obj.Status
+				data.keynext = m.obj.keynext
+			}
+			err = m.Key.Close()
+			if err == nil && m.val.buffer != nil && !consumed(data) {
+				err = m.val.Close()
+			}
+			if err != nil {
+				if more {
+					yield(nil, err)
+				}
+				return
+			} else if !more {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+//go:build go1.23
+
+package jsonmuncher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestElements(t *testing.T) {
+	json := "[1,[2,3],{\"a\":4},\"five\",6]"
+	r := strings.NewReader(json)
+	v1, _ := Parse(r, 4)
+	var nums []float64
+	for v, e := range v1.Elements() {
+		assert(t, e != nil,
+			"1", e)
+		if v.Type == Number {
+			n, _ := v.ValueNum()
+			nums = append(nums, n)
+		}
+	}
+	assert(t, len(nums) != 2 || nums[0] != 1 || nums[1] != 6 || v1.Status != Complete,
+		"2", nums, v1.Status)
+	r.Reset(json)
+	v1, _ = Parse(r, 4)
+	for v := range v1.Elements() {
+		if v.Type == Array {
+			break
+		}
+	}
+	v2, e := v1.NextValue()
+	assert(t, v2.Type != Object || e != nil,
+		"3", v2.Type, e)
+	v1, _ = Parse(strings.NewReader("[1,}"), 4)
+	count := 0
+	for v, e := range v1.Elements() {
+		count++
+		if count == 2 {
+			assert(t, v != nil || e == nil || e.Error() != "Unexpected '}' at file offset 3, expected one of '{', '[', '\"', 'n', 't', 'f', '-', '0'-'9'",
+				"4", v, e)
+		}
+	}
+	assert(t, count != 2,
+		"5", count)
+	v1, _ = Parse(strings.NewReader("{}"), 4)
+	for _, e = range v1.Elements() {
+		assert(t, e == nil || e.Error() != "Method cannot be called on type Object, only on Array",
+			"6", e)
+	}
+}
+
+func TestMembers(t *testing.T) {
+	json := "{\"a\":1,\"b\":[2,3],\"c\":{\"d\":4},\"e\":5}"
+	r := strings.NewReader(json)
+	v1, _ := Parse(r, 4)
+	var nums []float64
+	for m, e := range v1.Members() {
+		assert(t, e != nil,
+			"1", e)
+		k, match, _ := m.Key.Compare("a", "e", "c")
+		if !match {
+			continue
+		}
+		v, e := m.Value()
+		assert(t, e != nil,
+			"2", e)
+		if k == "c" {
+			k2, _ := v.NextKey()
+			k2.Close()
+			continue
+		}
+		n, _ := v.ValueNum()
+		nums = append(nums, n)
+		v2, _ := m.Value()
+		assert(t, v2 != v,
+			"3", v2, v)
+	}
+	assert(t, len(nums) != 2 || nums[0] != 1 || nums[1] != 5 || v1.Status != Complete,
+		"4", nums, v1.Status)
+	p := NewParser(r, 8)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(json)
+		p.Reset(r)
+		v1, _ := p.Next()
+		for m := range v1.Members() {
+			v, _ := m.Value()
+			if v.Type == Array {
+				for range v.Elements() {
+				}
+			}
+		}
+	})
+	assert(t, allocs != 0,
+		"5", allocs)
+}
@@ -0,0 +1,44 @@
+package jsonmuncher
+
+import (
+	"io"
+)
+
+// Limits places bounds on the input accepted by the parser, as a defense
+// against hostile or malformed data. Each field is a separate limit, and a zero
+// value means that limit isn't enforced, so the zero Limits accepts anything.
+// When a limit is exceeded, a distinct error type is returned, which carries the
+// file offset at which the problem was found, and the path to the value being
+// read if paths are tracked (see ParseWithPath).
+//
+// MaxStringBytes, MaxNumberDigits, and MaxMembers are checked as values are
+// read. Close() discards data without reading it into memory, so the data it
+// skips over is only subject to MaxTotalBytes.
+type Limits struct {
+	// MaxDepth is the maximum nesting depth of Objects and Arrays. A top-level
+	// Object or Array has a depth of 1. Exceeding it returns ErrDepthLimit.
+	MaxDepth uint32
+	// MaxStringBytes is the maximum length of a String or key, in bytes, after
+	// escape sequences are decoded. Exceeding it returns ErrStringLimit.
+	MaxStringBytes uint64
+	// MaxNumberDigits is the maximum number of digits in a Number literal,
+	// counting the integer, fraction, and exponent. Exceeding it returns
+	// ErrNumberLimit.
+	MaxNumberDigits uint32
+	// MaxTotalBytes is the maximum number of bytes read from the stream,
+	// including whitespace. Exceeding it returns ErrTotalLimit.
+	MaxTotalBytes uint64
+	// MaxMembers is the maximum number of keys in a single Object. Exceeding it
+	// returns ErrMemberLimit.
+	MaxMembers uint64
+}
+
+// ParseWithLimits is like Parse, but rejects any input that exceeds the given
+// limits. To combine limits with a context or path tracking, use a Parser.
+func ParseWithLimits(r io.Reader, size int, lim Limits) (JsonValue, error) {
+	buf := newOptBuffer(r, size)
+	buf.opts.limits = lim
+	_ = feedq(buf) && feed(buf)
+	next(buf)
+	return readValue(buf)
+}
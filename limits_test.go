@@ -0,0 +1,162 @@
+package jsonmuncher
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDepthLimit(t *testing.T) {
+	lim := Limits{MaxDepth: 2}
+	v1, e := ParseWithLimits(strings.NewReader("[[1],{\"a\":[]}]"), 4, lim)
+	assert(t, v1.Type != Array || e != nil,
+		"1", v1.Type, e)
+	v2, e := v1.NextValue()
+	assert(t, v2.Type != Array || e != nil,
+		"2", v2.Type, e)
+	e = v2.Close()
+	assert(t, e != nil,
+		"3", e)
+	v2, _ = v1.NextValue()
+	_, e = v2.NextValue()
+	assert(t, e != ErrDepthLimit{10, 2, ""},
+		"4", e)
+	assert(t, e.Error() != "Nesting depth exceeds limit of 2 at file offset 10",
+		"5", e.Error())
+	assert(t, v2.Status != Incomplete,
+		"6", v2.Status)
+	_, e = ParseWithLimits(strings.NewReader("[]"), 4, Limits{MaxDepth: 1})
+	assert(t, e != nil,
+		"7", e)
+	v1, _ = ParseWithLimits(strings.NewReader("\"str\""), 4, Limits{MaxDepth: 1})
+	assert(t, v1.Type != String,
+		"8", v1.Type)
+}
+
+func TestStringLimit(t *testing.T) {
+	var buf [16]byte
+	lim := Limits{MaxStringBytes: 4}
+	v1, _ := ParseWithLimits(strings.NewReader("[\"abcd\",\"\\u00e9\\u00e9\",\"abcde\"]"), 4, lim)
+	v2, _ := v1.NextValue()
+	s, e := v2.Read(buf[:])
+	assert(t, e != io.EOF || string(buf[:s]) != "abcd",
+		"1", string(buf[:s]), e)
+	v2, _ = v1.NextValue()
+	s, e = v2.Read(buf[:])
+	assert(t, e != io.EOF || string(buf[:s]) != "éé",
+		"2", string(buf[:s]), e)
+	v2, _ = v1.NextValue()
+	s, e = v2.Read(buf[:2])
+	assert(t, e != nil || string(buf[:s]) != "ab",
+		"3", string(buf[:s]), e)
+	s, e = v2.Read(buf[:])
+	assert(t, e != ErrStringLimit{23, 4, ""} || string(buf[:s]) != "cd",
+		"4", string(buf[:s]), e)
+	assert(t, e.Error() != "String length exceeds limit of 4 bytes at file offset 23",
+		"5", e.Error())
+	v1, _ = ParseWithLimits(strings.NewReader("{\"abc\":1,\"abcde\":2}"), 4, lim)
+	_, _, m, e := v1.FindKey("abcde")
+	assert(t, m != false || e != ErrStringLimit{9, 4, ""},
+		"6", m, e)
+}
+
+func TestNumberLimit(t *testing.T) {
+	lim := Limits{MaxNumberDigits: 4}
+	v1, _ := ParseWithLimits(strings.NewReader("[-12.34,1e999,12345]"), 4, lim)
+	v2, _ := v1.NextValue()
+	n, e := v2.ValueNum()
+	assert(t, n != -12.34 || e != nil,
+		"1", n, e)
+	v2, _ = v1.NextValue()
+	_, e = v2.ValueNum()
+	assert(t, e != ErrNumRange{"float64", false, ""},
+		"2", e)
+	v2, _ = v1.NextValue()
+	_, e = v2.ValueNum()
+	assert(t, e != ErrNumberLimit{14, 4, ""},
+		"3", e)
+	assert(t, e.Error() != "Number length exceeds limit of 4 digits at file offset 14",
+		"4", e.Error())
+	v1, _ = ParseWithLimits(strings.NewReader("[12345,1]"), 4, lim)
+	v2, _ = v1.NextValue()
+	e = v2.Close()
+	assert(t, e != nil,
+		"5", e)
+	v2, _ = v1.NextValue()
+	n, e = v2.ValueNum()
+	assert(t, n != 1 || e != nil,
+		"6", n, e)
+}
+
+func TestTotalLimit(t *testing.T) {
+	lim := Limits{MaxTotalBytes: 10}
+	v1, _ := ParseWithLimits(strings.NewReader("[1, 2, 3]\n"), 4, lim)
+	e := v1.Close()
+	assert(t, e != nil,
+		"1", e)
+	v1, _ = ParseWithLimits(strings.NewReader("[1, 2, 3, 4]"), 4, lim)
+	v2, _ := v1.NextValue()
+	v2.Close()
+	v2, _ = v1.NextValue()
+	v2.Close()
+	v2, _ = v1.NextValue()
+	v2.Close()
+	_, e = v1.NextValue()
+	assert(t, e != ErrTotalLimit{10, 10, ""},
+		"2", e)
+	assert(t, e.Error() != "Input length exceeds limit of 10 bytes at file offset 10",
+		"3", e.Error())
+	v1, _ = ParseWithLimits(strings.NewReader("[\"aaaaaaaaaaaaaaaaaaaa\"]"), 4, lim)
+	e = v1.Close()
+	assert(t, e != ErrTotalLimit{10, 10, ""},
+		"4", e)
+	p := NewParser(strings.NewReader("1 2 3 4 5 6"), 4)
+	p.Limits = lim
+	for i := 0; i < 5; i++ {
+		v1, e = p.Next()
+		assert(t, e != nil,
+			"5", i, e)
+		v1.Close()
+	}
+	_, e = p.Next()
+	assert(t, e != ErrTotalLimit{10, 10, ""},
+		"6", e)
+	p.ResetBytes([]byte("1 2 3 4 5 6"))
+	for i := 0; i < 5; i++ {
+		v1, e = p.Next()
+		assert(t, e != nil,
+			"7", i, e)
+		v1.Close()
+	}
+	_, e = p.Next()
+	assert(t, e != ErrTotalLimit{10, 10, ""},
+		"8", e)
+	p.Limits = Limits{MaxTotalBytes: 4}
+	p.ResetBytes([]byte(`{"a": [1, 2]}`))
+	v1, _ = p.Next()
+	e = v1.Close()
+	assert(t, e != ErrTotalLimit{4, 4, ""},
+		"9", e)
+}
+
+func TestMemberLimit(t *testing.T) {
+	lim := Limits{MaxMembers: 2}
+	v1, _ := ParseWithLimits(strings.NewReader("{\"a\":{\"b\":1,\"c\":2},\"d\":3,\"e\":4}"), 4, lim)
+	_, v2, m, e := v1.FindKey("a")
+	assert(t, m != true || e != nil,
+		"1", m, e)
+	e = v2.Close()
+	assert(t, e != nil,
+		"2", e)
+	k, e := v1.NextKey()
+	assert(t, e != nil,
+		"3", e)
+	k.Close()
+	_, e = v1.NextKey()
+	assert(t, e != ErrMemberLimit{25, 2, ""},
+		"4", e)
+	assert(t, e.Error() != "Object size exceeds limit of 2 members at file offset 25",
+		"5", e.Error())
+	assert(t, v1.Status != Incomplete,
+		"6", v1.Status)
+}
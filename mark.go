@@ -0,0 +1,136 @@
+package jsonmuncher
+
+import (
+	"io"
+)
+
+// Mark is a position in the stream, recorded by JsonValue.Mark(), that the
+// parser can be returned to.
+type Mark struct {
+	// val is the value as it was when it was marked.
+	val JsonValue
+	// fpos is the file offset of the lookahead byte, and spos is the position
+	// of the same byte in the underlying stream, which may not have started at
+	// the beginning.
+	fpos uint64
+	spos int64
+	// The rest of the parser state, as it was when the value was marked.
+	depth   uint32
+	escapes byte
+	escape1 byte
+	escape2 byte
+	escape3 byte
+	escape4 byte
+	strlen  uint64
+	peeked  uint32
+	orphans []uint32
+	path    []byte
+	segs    uint32
+	segoffs uint32
+	pathcut bool
+	capture bool
+}
+
+// Mark records the current position of this value, so that it can be read
+// again later with Rewind(). This requires the stream to be an io.Seeker (such
+// as an *os.File), or the value to have been read with ParseBytes(); otherwise,
+// ErrNotSeekable is returned. Marking a value doesn't allocate, unless it has
+// ancestors left open by Seek() or paths are being tracked, in which case that
+// state is copied.
+func (data *JsonValue) Mark() (Mark, error) {
+	buf := data.buffer
+	if buf.err != nil && buf.err != io.EOF {
+		return Mark{}, buf.err
+	}
+	// The lookahead byte is the last byte taken from the read buffer, unless
+	// the end of the stream has been reached.
+	rel := buf.offs - 1
+	if buf.err != nil {
+		rel = buf.erroffs
+	}
+	m := Mark{val: *data, fpos: buf.foffs - uint64(len(buf.data)) + uint64(rel),
+		depth: buf.depth, escapes: buf.escapes, escape1: buf.escape1,
+		escape2: buf.escape2, escape3: buf.escape3, escape4: buf.escape4,
+		strlen: buf.strlen, peeked: buf.peeked}
+	if buf.stream != nil {
+		s, ok := buf.stream.(io.Seeker)
+		if !ok {
+			return Mark{}, ErrNotSeekable
+		}
+		end, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return Mark{}, err
+		}
+		m.spos = end - int64(buf.erroffs-rel)
+	}
+	o := buf.opts
+	if o == nil {
+		return m, nil
+	}
+	if len(o.orphans) > 0 {
+		m.orphans = append([]uint32(nil), o.orphans...)
+	}
+	if o.path != nil {
+		if o.capture {
+			// Bytes of the key up to the lookahead byte are captured now, since
+			// the read buffer won't hold them once the stream is rewound.
+			captureKey(buf, rel)
+		}
+		m.path = append([]byte(nil), o.path...)
+		m.segs, m.segoffs = o.segs, o.segoffs
+		m.pathcut, m.capture = o.pathcut, o.capture
+	}
+	return m, nil
+}
+
+// Rewind returns the parser to the position recorded by the Mark, and returns
+// the marked value as it was at that time, so it can be read again. Any values
+// read since the Mark was made become invalid. The Mark remains valid, so the
+// parser can be rewound to it any number of times.
+//
+// Only the marked value and the values within it are rewound. Its parent and
+// other ancestors must not have been used since the Mark was made (except to
+// close the marked value), or they will be left in an inconsistent state.
+func (m *Mark) Rewind() (JsonValue, error) {
+	buf := m.val.buffer
+	if buf == nil {
+		return JsonValue{}, ErrIncomplete
+	}
+	if buf.stream == nil {
+		// The whole input is held in the read buffer, so there is nothing to
+		// seek; the buffer only needs to be restored if it has been fed.
+		buf.foffs = uint64(len(buf.data))
+		buf.erroffs = uint32(len(buf.data))
+		buf.readerr = io.EOF
+		buf.offs = uint32(m.fpos)
+	} else {
+		_, err := buf.stream.(io.Seeker).Seek(m.spos, io.SeekStart)
+		if err != nil {
+			return JsonValue{}, err
+		}
+		buf.foffs = m.fpos
+		buf.offs = uint32(len(buf.data))
+		feed(buf)
+	}
+	buf.err = nil
+	next(buf)
+	if buf.err != nil && buf.err != io.EOF {
+		return JsonValue{}, buf.err
+	}
+	buf.depth = m.depth
+	buf.escapes = m.escapes
+	buf.escape1, buf.escape2 = m.escape1, m.escape2
+	buf.escape3, buf.escape4 = m.escape3, m.escape4
+	buf.strlen = m.strlen
+	buf.peeked = m.peeked
+	if o := buf.opts; o != nil {
+		o.orphans = append(o.orphans[:0], m.orphans...)
+		if o.path != nil {
+			o.path = append(o.path[:0], m.path...)
+			o.segs, o.segoffs = m.segs, m.segoffs
+			o.pathcut, o.capture = m.pathcut, m.capture
+			o.capoffs = 0
+		}
+	}
+	return m.val, nil
+}
@@ -0,0 +1,143 @@
+package jsonmuncher
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMark(t *testing.T) {
+	json := "[0, {\"type\": \"circle\", \"r\": 2.5, \"s\": \"a\\u00e9\\nb\"}, 3]"
+	for size := 1; size <= len(json); size++ {
+		v1, _ := Parse(bytes.NewReader([]byte(json)), size)
+		v2, _ := v1.NextValue()
+		v2.Close()
+		v2, _ = v1.NextValue()
+		m, e := v2.Mark()
+		assert(t, e != nil,
+			"1", size, e)
+		_, v3, _, _ := v2.FindKey("type")
+		typ, _, _ := v3.Compare("circle", "square")
+		assert(t, typ != "circle",
+			"2", size, typ)
+		v2.Close()
+		for i := 0; i < 2; i++ {
+			v2, e = m.Rewind()
+			assert(t, v2.Type != Object || e != nil,
+				"3", size, i, v2.Type, e)
+			var keys []string
+			for {
+				k, e := v2.NextKey()
+				if e == EndOfValue {
+					break
+				}
+				kb, _ := io.ReadAll(&k)
+				keys = append(keys, string(kb))
+				v3, _ := v2.NextValue()
+				v3.Close()
+			}
+			assert(t, strings.Join(keys, ",") != "type,r,s",
+				"4", size, i, keys)
+		}
+		v2, _ = v1.NextValue()
+		n, _ := v2.ValueNum()
+		assert(t, n != 3,
+			"5", size, n)
+		_, e = v1.NextValue()
+		assert(t, e != EndOfValue,
+			"6", size, e)
+	}
+}
+
+func TestMarkString(t *testing.T) {
+	json := "\"ab\\u00e9cd\""
+	for size := 1; size <= len(json); size++ {
+		v1, _ := Parse(strings.NewReader(json), size)
+		var buf [3]byte
+		n, _ := v1.Read(buf[:3])
+		m, _ := v1.Mark()
+		rest, e := io.ReadAll(&v1)
+		assert(t, string(buf[:n]) != "ab\xc3" || string(rest) != "\xa9cd" || e != nil,
+			"1", size, string(buf[:n]), string(rest), e)
+		v1, e = m.Rewind()
+		rest, _ = io.ReadAll(&v1)
+		assert(t, string(rest) != "\xa9cd" || e != nil,
+			"2", size, string(rest), e)
+		e = v1.Finish()
+		assert(t, e != nil,
+			"3", size, e)
+	}
+}
+
+func TestMarkBytes(t *testing.T) {
+	v1, _ := ParseBytes([]byte("{\"a\": [1, 2], \"b\": true}"))
+	m1, _ := v1.Mark()
+	v2, _, _ := v1.Seek("/a")
+	m2, _ := v2.Mark()
+	v3, _ := v2.NextValue()
+	n, _ := v3.ValueNum()
+	assert(t, n != 1,
+		"1", n)
+	v2.Close()
+	v2, e := m2.Rewind()
+	assert(t, v2.Type != Array || e != nil,
+		"2", v2.Type, e)
+	v3, _ = v2.NextValue()
+	v3.Close()
+	v3, _ = v2.NextValue()
+	n, _ = v3.ValueNum()
+	assert(t, n != 2,
+		"3", n)
+	v2.Close()
+	e = v1.Finish()
+	assert(t, e != nil,
+		"4", e)
+	v1, e = m1.Rewind()
+	assert(t, v1.Type != Object || e != nil,
+		"5", v1.Type, e)
+	v2, _, e = v1.Seek("/b")
+	assert(t, v2.Type != Bool || !v2.boolval || e != nil,
+		"6", v2.Type, e)
+	e = v1.Finish()
+	assert(t, e != nil,
+		"7", e)
+}
+
+func TestMarkPath(t *testing.T) {
+	json := "{\"outer\": {\"key\": [1, 2]}}"
+	for size := 1; size <= len(json); size++ {
+		v1, _ := ParseWithPath(strings.NewReader(json), size, make([]byte, 0, 32))
+		v2, _, _ := v1.Seek("/outer")
+		k, _ := v2.NextKey()
+		var buf [1]byte
+		k.Read(buf[:])
+		m, _ := k.Mark()
+		k.Close()
+		assert(t, k.Path() != "/outer/key",
+			"1", size, k.Path())
+		k, _ = m.Rewind()
+		assert(t, k.Path() != "/outer",
+			"2", size, k.Path())
+		k.Close()
+		v3, _ := v2.NextValue()
+		m, _ = v3.Mark()
+		v4, _ := v3.NextValue()
+		v4.Close()
+		v4, _ = v3.NextValue()
+		assert(t, v4.Path() != "/outer/key/1",
+			"3", size, v4.Path())
+		v4.Close()
+		v3, _ = m.Rewind()
+		v4, _ = v3.NextValue()
+		assert(t, v4.Path() != "/outer/key/0",
+			"4", size, v4.Path())
+	}
+}
+
+func TestMarkNotSeekable(t *testing.T) {
+	v1, _ := Parse(io.MultiReader(strings.NewReader("[1]")), 4)
+	_, e := v1.Mark()
+	assert(t, e != ErrNotSeekable,
+		"1", e)
+}
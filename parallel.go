@@ -0,0 +1,421 @@
+package jsonmuncher
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelBufSize is the size of the read buffers used by each worker in
+// ParallelArray.
+const parallelBufSize = 32 << 10
+
+// parallelMinChunk is the smallest chunk that ParallelArray splits an Array
+// into. It is a variable so that tests can make the chunks tiny.
+var parallelMinChunk int64 = 64 << 10
+
+// The states a chunk of an Array can begin in: outside of a String, within one,
+// or within one just after a backslash.
+const (
+	scanOut byte = iota
+	scanIn
+	scanEsc
+)
+
+// scanner follows the structure of an Array closely enough to find the commas
+// and brackets outside of Strings, in the same way as skip.
+type scanner struct {
+	state byte
+	depth int64
+}
+
+// scanSpecial marks the bytes that the scanner has to look at outside of a
+// String (scanOut) and within one (scanIn).
+var scanSpecial = func() (t [2][256]bool) {
+	for _, c := range []byte("\"{}[],") {
+		t[scanOut][c] = true
+	}
+	t[scanIn]['"'] = true
+	t[scanIn]['\\'] = true
+	return
+}()
+
+// scan runs the scanner over a block of the Array, which begins at the given
+// file offset. visit is called with the offset, the new depth, and the byte
+// itself for each comma outside of a String at a depth of 0 or less, and for
+// each closing bracket that takes the depth below 0. If visit returns false,
+// scanning stops.
+func (s *scanner) scan(b []byte, offs int64, visit func(int64, int64, byte) bool) bool {
+	state, depth := s.state, s.depth
+	for i := 0; i < len(b); i++ {
+		if state == scanEsc {
+			state = scanIn
+			continue
+		}
+		special := &scanSpecial[state]
+		for i < len(b) && !special[b[i]] {
+			i++
+		}
+		if i == len(b) {
+			break
+		}
+		c := b[i]
+		if state == scanIn {
+			if c == '\\' {
+				state = scanEsc
+			} else {
+				state = scanOut
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			state = scanIn
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth < 0 && !visit(offs+int64(i), depth, c) {
+				s.state, s.depth = state, depth
+				return false
+			}
+		case ',':
+			if depth <= 0 && !visit(offs+int64(i), depth, c) {
+				s.state, s.depth = state, depth
+				return false
+			}
+		}
+	}
+	s.state, s.depth = state, depth
+	return true
+}
+
+// chunkScan is the result of scanning a chunk from one of the states it may
+// begin in, with depths relative to the start of the chunk. Since the depth at
+// the start is at least 1, only commas at a depth of 0 or less can separate
+// elements. commas holds the offset of the first comma at each of the depths 0,
+// -1, -2, and so on, or -1 if there isn't one. drops holds the offset at which
+// the depth first falls to -1, -2, and so on.
+type chunkScan struct {
+	scanner
+	commas []int64
+	drops  []int64
+}
+
+// record is the visit function for a chunkScan.
+func (s *chunkScan) record(offs int64, depth int64, c byte) bool {
+	if c != ',' {
+		if int64(len(s.drops)) < -depth {
+			s.drops = append(s.drops, offs)
+		}
+		return true
+	}
+	for int64(len(s.commas)) <= -depth {
+		s.commas = append(s.commas, -1)
+	}
+	if s.commas[-depth] < 0 {
+		s.commas[-depth] = offs
+	}
+	return true
+}
+
+// arrayChunk is a part of the Array read by ParallelArray.
+type arrayChunk struct {
+	start int64
+	end   int64
+	// scans holds the results of scanning the chunk from each state.
+	scans [3]chunkScan
+	// state and depth are what the chunk actually begins in, once they are
+	// known. The top-level Array is at a depth of 1.
+	state byte
+	depth int64
+	// next is the offset of the first comma or bracket after the chunk that
+	// ends an element.
+	next int64
+}
+
+// arrayWorker holds the read buffers of one of the goroutines used by
+// ParallelArray.
+type arrayWorker struct {
+	r     io.ReaderAt
+	data  []byte
+	pdata []byte
+	sr    io.SectionReader
+	buf   buffer
+}
+
+// parallel runs the passes of ParallelArray over a set of workers, and keeps
+// the first error.
+type parallel struct {
+	workers []arrayWorker
+	done    chan struct{}
+	once    sync.Once
+	err     error
+}
+
+// ParallelArray reads a top-level Array from the first size bytes of r, and
+// calls fn for each of its elements, using the given number of workers to parse
+// elements concurrently. This is meant for very large files that consist of a
+// single array of records.
+//
+// The Array is split into chunks, which are scanned concurrently for the
+// boundaries between elements, in the same way Close() skips over data. Whether
+// a chunk begins within a String can't be known until everything before it
+// has been scanned, so each chunk is scanned from every state it might begin
+// in, and the results are then pieced together in order. The workers go on to
+// parse the elements that begin in each chunk, each with its own read buffer,
+// straight from r. The scan doesn't check the syntax of the elements; that is
+// left to the parse.
+//
+// fn is called from several goroutines at once, and in no particular order. Once
+// fn returns, anything it left unread is discarded without being parsed, since
+// each element is read only up to the comma or bracket after it. If fn returns
+// an error, or an element can't be parsed, no further elements are handed out,
+// and the first error is returned once the workers have stopped. File offsets
+// in errors are relative to the start of r.
+func ParallelArray(r io.ReaderAt, size int64, workers int, fn func(JsonValue) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+	arr, err := Parse(io.NewSectionReader(r, 0, size), parallelBufSize)
+	if err != nil {
+		return err
+	} else if arr.Type != Array {
+		return newErrTypeMismatch(arr.Type, Array)
+	}
+	_, more, err := arr.Peek()
+	if err != nil || !more {
+		return err
+	}
+	lo := int64(arr.offs) + 1
+	chunks := splitChunks(lo, size, workers)
+	p := &parallel{workers: make([]arrayWorker, workers), done: make(chan struct{})}
+	for i := range p.workers {
+		p.workers[i].r = r
+	}
+	p.run(len(chunks), func(w *arrayWorker, k int) error {
+		return w.scanChunk(&chunks[k])
+	})
+	if p.err != nil {
+		return p.err
+	}
+	chunks, err = resolveChunks(chunks, size)
+	if err != nil {
+		return err
+	}
+	p.run(len(chunks), func(w *arrayWorker, k int) error {
+		start := int64(-1)
+		if k == 0 {
+			start = lo
+		}
+		return w.readChunk(&chunks[k], start, fn, p)
+	})
+	return p.err
+}
+
+// splitChunks divides the bytes from lo to size into chunks, several for each
+// worker, so that the work is spread evenly, but no smaller than
+// parallelMinChunk.
+func splitChunks(lo int64, size int64, workers int) []arrayChunk {
+	n := int64(workers) * 4
+	per := (size - lo + n - 1) / n
+	if per < parallelMinChunk {
+		per = parallelMinChunk
+	}
+	var chunks []arrayChunk
+	for start := lo; start < size; start += per {
+		end := start + per
+		if end > size {
+			end = size
+		}
+		chunks = append(chunks, arrayChunk{start: start, end: end})
+	}
+	return chunks
+}
+
+// resolveChunks works out the state and depth that each chunk actually begins
+// in, going through them in order, and finds the end of the Array. The chunks
+// after the one the Array ends in are dropped.
+func resolveChunks(chunks []arrayChunk, size int64) ([]arrayChunk, error) {
+	state, depth := scanOut, int64(1)
+	last := -1
+	for k := range chunks {
+		c := &chunks[k]
+		c.state, c.depth = state, depth
+		s := &c.scans[state]
+		if int64(len(s.drops)) >= depth {
+			last = k
+			break
+		}
+		state, depth = s.state, depth+s.depth
+	}
+	if last < 0 {
+		return nil, newErrUnexpectedEOF(uint64(size), ',', ']')
+	}
+	chunks = chunks[:last+1]
+	c := &chunks[last]
+	next := c.scans[c.state].drops[c.depth-1]
+	for k := last; k >= 0; k-- {
+		c = &chunks[k]
+		c.next = next
+		s := &c.scans[c.state]
+		if i := c.depth - 1; i < int64(len(s.commas)) && s.commas[i] >= 0 && s.commas[i] < next {
+			next = s.commas[i]
+		}
+	}
+	return chunks, nil
+}
+
+// run calls fn for each chunk from 0 to n-1, with each worker taking the next
+// chunk as it becomes free, and waits for them to finish. No more chunks are
+// handed out once an error occurs.
+func (p *parallel) run(n int, fn func(*arrayWorker, int) error) {
+	next := int64(-1)
+	var wg sync.WaitGroup
+	for i := range p.workers {
+		wg.Add(1)
+		go func(w *arrayWorker) {
+			defer wg.Done()
+			for {
+				k := int(atomic.AddInt64(&next, 1))
+				if k >= n || p.stopped() {
+					return
+				}
+				err := fn(w, k)
+				if err != nil {
+					p.fail(err)
+					return
+				}
+			}
+		}(&p.workers[i])
+	}
+	wg.Wait()
+}
+
+// fail records an error, and stops the workers.
+func (p *parallel) fail(err error) {
+	p.once.Do(func() {
+		p.err = err
+		close(p.done)
+	})
+}
+
+// stopped is true once an error has occurred.
+func (p *parallel) stopped() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// readBlocks reads the bytes from start to end, a buffer at a time, and passes
+// each block to fn along with its file offset. If fn returns false, reading
+// stops.
+func (w *arrayWorker) readBlocks(start int64, end int64, fn func([]byte, int64) bool) error {
+	if w.data == nil {
+		w.data = make([]byte, parallelBufSize)
+	}
+	for offs := start; offs < end; offs += int64(len(w.data)) {
+		b := w.data
+		if int64(len(b)) > end-offs {
+			b = b[:end-offs]
+		}
+		n, err := w.r.ReadAt(b, offs)
+		if n < len(b) {
+			if err == nil || err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return err
+		} else if !fn(b, offs) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// scanChunk scans a chunk from each of the states it may begin in. Unless the
+// chunk begins with a quote or a backslash, a String is left in the same state
+// after the first byte, whether or not it was escaped, so the scan from scanIn
+// serves for scanEsc as well.
+func (w *arrayWorker) scanChunk(c *arrayChunk) error {
+	for i := range c.scans {
+		c.scans[i] = chunkScan{scanner: scanner{state: byte(i)}}
+	}
+	n := len(c.scans)
+	err := w.readBlocks(c.start, c.end, func(b []byte, offs int64) bool {
+		if offs == c.start && b[0] != '"' && b[0] != '\\' {
+			n = int(scanEsc)
+		}
+		for i := 0; i < n; i++ {
+			c.scans[i].scan(b, offs, c.scans[i].record)
+		}
+		return true
+	})
+	if n == int(scanEsc) {
+		c.scans[scanEsc] = c.scans[scanIn]
+	}
+	return err
+}
+
+// readChunk scans a chunk from the state it actually begins in, and parses each
+// element that begins within it. If start isn't -1, it is the offset of an
+// element that begins before the first comma in the chunk.
+func (w *arrayWorker) readChunk(c *arrayChunk, start int64, fn func(JsonValue) error, p *parallel) error {
+	// The depth is shifted so that the commas between elements are at 0, and
+	// the end of the Array takes it to -1.
+	s := scanner{c.state, c.depth - 1}
+	var err error
+	ended := false
+	rerr := w.readBlocks(c.start, c.end, func(b []byte, offs int64) bool {
+		return s.scan(b, offs, func(at int64, depth int64, c byte) bool {
+			if c == ',' && depth != 0 {
+				return true
+			} else if c == '}' {
+				// The scan doesn't tell brackets apart, but the parse does.
+				err = newErrUnexpectedChar(uint64(at), c, ',', ']')
+			} else if start >= 0 {
+				err = w.parseElement(start, at, fn)
+			}
+			start, ended = at+1, c != ','
+			return err == nil && !ended && !p.stopped()
+		})
+	})
+	if rerr != nil {
+		return rerr
+	} else if err != nil || ended || start < 0 || p.stopped() {
+		return err
+	}
+	return w.parseElement(start, c.next, fn)
+}
+
+// parseElement parses the element that begins at the given offset and ends
+// before the comma or bracket at end, and passes it to fn. If fn reads the
+// element in its entirety, the comma or bracket is expected to follow it.
+func (w *arrayWorker) parseElement(start int64, end int64, fn func(JsonValue) error) error {
+	if w.pdata == nil {
+		w.pdata = make([]byte, parallelBufSize)
+	}
+	w.sr = *io.NewSectionReader(w.r, start, end+1-start)
+	// The file offset starts at the beginning of the element, so offsets are
+	// relative to the start of r.
+	w.buf = buffer{data: w.pdata, stream: &w.sr, offs: uint32(len(w.pdata)),
+		foffs: uint64(start)}
+	buf := &w.buf
+	_ = feedq(buf) && feed(buf)
+	next(buf)
+	val, err := readValue(buf)
+	if err != nil {
+		return err
+	}
+	err = fn(val)
+	if err != nil || buf.depth != 0 {
+		return err
+	}
+	_, err = skipSpace(buf)
+	if err == nil && (buf.err != nil || foffs(buf) != uint64(end)) {
+		err = newErrUnexpected(buf, ',', ']')
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package jsonmuncher
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParallelArray(t *testing.T) {
+	var bld strings.Builder
+	bld.WriteString(" [\n")
+	for i := 0; i < 1000; i++ {
+		if i > 0 {
+			bld.WriteString(",\n")
+		}
+		switch i % 4 {
+		case 0:
+			bld.WriteString("{\"id\": " + strconv.Itoa(i) + ", \"s\": \"]}\\\"\"}")
+		case 1:
+			bld.WriteString("[" + strconv.Itoa(i) + ", [{}]]")
+		case 2:
+			bld.WriteString(strconv.Itoa(i))
+		case 3:
+			bld.WriteString("\"" + strconv.Itoa(i) + "\"")
+		}
+	}
+	bld.WriteString("\n] ")
+	json := bld.String()
+	for _, workers := range []int{0, 1, 4} {
+		var mu sync.Mutex
+		sum, count := 0, 0
+		e := ParallelArray(strings.NewReader(json), int64(len(json)), workers, func(v JsonValue) error {
+			var n float64
+			var err error
+			switch v.Type {
+			case Object:
+				_, v2, _, _ := v.FindKey("id")
+				n, err = v2.ValueNum()
+			case Array:
+				v2, _ := v.NextValue()
+				n, err = v2.ValueNum()
+			case Number:
+				n, err = v.ValueNum()
+			case String:
+				var buf [8]byte
+				l, _ := v.Read(buf[:])
+				n, err = strconv.ParseFloat(string(buf[:l]), 64)
+			}
+			mu.Lock()
+			sum += int(n)
+			count++
+			mu.Unlock()
+			return err
+		})
+		assert(t, e != nil || count != 1000 || sum != 999*1000/2,
+			"1", workers, e, count, sum)
+	}
+}
+
+func TestParallelArrayErrors(t *testing.T) {
+	json := "[1, {\"a\": tru}, 3]"
+	e := ParallelArray(strings.NewReader(json), int64(len(json)), 2, func(v JsonValue) error {
+		if v.Type == Object {
+			_, _, _, err := v.FindKey("a")
+			return err
+		}
+		return nil
+	})
+	assert(t, e == nil || e.Error() != "Unexpected '}' at file offset 13, expected 'e'",
+		"1", e)
+	errStop := errors.New("stop")
+	json = "[1, 2, 3, 4, 5, 6, 7, 8]"
+	e = ParallelArray(strings.NewReader(json), int64(len(json)), 2, func(v JsonValue) error {
+		n, _ := v.ValueNum()
+		if n == 4 {
+			return errStop
+		}
+		return nil
+	})
+	assert(t, e != errStop,
+		"2", e)
+	json = "{\"a\": 1}"
+	e = ParallelArray(strings.NewReader(json), int64(len(json)), 2, func(v JsonValue) error {
+		return nil
+	})
+	assert(t, e == nil || e.Error() != "Method cannot be called on type Object, only on Array",
+		"3", e)
+	json = "[1, 2"
+	e = ParallelArray(strings.NewReader(json), int64(len(json)), 2, func(v JsonValue) error {
+		return nil
+	})
+	assert(t, e == nil,
+		"4", e)
+	json = "[]"
+	e = ParallelArray(strings.NewReader(json), int64(len(json)), 2, func(v JsonValue) error {
+		t.Error("unexpected call")
+		return nil
+	})
+	assert(t, e != nil,
+		"5", e)
+}
+
+func TestParallelArrayChunks(t *testing.T) {
+	defer func(min int64) { parallelMinChunk = min }(parallelMinChunk)
+	parallelMinChunk = 1
+	json := "[\"a\\\\\\\"],\" , {\"b\": [\"\\\\\", \"]\", {}]},[], \"x,\" ,12,\n[[\"\\\"\"]], null] ,[1]"
+	want := []string{"\"a\\\\\\\"],\"", "{\"b\": [\"\\\\\", \"]\", {}]}", "[]", "\"x,\"", "12",
+		"[[\"\\\"\"]]", "null"}
+	sort.Strings(want)
+	for workers := 1; workers <= 12; workers++ {
+		var mu sync.Mutex
+		var got []string
+		e := ParallelArray(strings.NewReader(json), int64(len(json)), workers, func(v JsonValue) error {
+			var bld strings.Builder
+			_, err := v.WriteRawTo(&bld)
+			mu.Lock()
+			got = append(got, bld.String())
+			mu.Unlock()
+			return err
+		})
+		sort.Strings(got)
+		assert(t, e != nil || strings.Join(got, "|") != strings.Join(want, "|"),
+			"1", workers, e, got)
+	}
+	for _, json := range []string{"[1 2]", "[1, {\"a\": 2}}", "[1,,2]", "[1, 2,]", "[1, [2]"} {
+		for workers := 1; workers <= 4; workers++ {
+			e := ParallelArray(strings.NewReader(json), int64(len(json)), workers, func(v JsonValue) error {
+				return v.Close()
+			})
+			assert(t, e == nil,
+				"2", json, workers)
+		}
+	}
+}
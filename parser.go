@@ -2,7 +2,9 @@
 package jsonmuncher
 
 import (
+	"context"
 	"io"
+	"math"
 	"strconv"
 	"unicode/utf8"
 	"unsafe"
@@ -54,6 +56,30 @@ type buffer struct {
 	escape3 byte
 	escape4 byte
 	curr    byte
+	strict  bool
+	numoffs uint64
+	numlit  []byte
+	numbuf  [32]byte
+	strlen  uint64
+	peeked  uint32
+	opts    *options
+}
+
+// options holds the state of the optional features of the parser: limits, a
+// context, the orphans left by Seek, and path tracking. It is kept apart from
+// the buffer so that a parse using none of them only carries a nil pointer, and
+// pays a single check for each.
+type options struct {
+	limits  Limits
+	ctx     context.Context
+	orphans []uint32
+	orphbuf [8]uint32
+	path    []byte
+	segs    uint32
+	segoffs uint32
+	pathcut bool
+	capture bool
+	capoffs uint32
 }
 
 // JsonValue represents a JSON value. This is the primary structure used in this
@@ -61,8 +87,13 @@ type buffer struct {
 type JsonValue struct {
 	// buffer is a pointer to the read buffer.
 	buffer *buffer
-	// numval is the parsed value, assuming this is a Number.
-	numval float64
+	// num is the magnitude of the parsed value, assuming this is a Number that
+	// is an integer small enough to fit; for any other Number, it holds the bits
+	// of the parsed float64. If this is an Object, the number of keys read so
+	// far, or if this is an Array, the number of elements.
+	num uint64
+	// offs is the file offset of the first byte of this value.
+	offs uint64
 	// depth is the nesting depth of this value.
 	depth uint32
 	// Type is the data type of this value.
@@ -70,11 +101,15 @@ type JsonValue struct {
 	// Status is the read status of this value.
 	Status JsonStatus
 	// boolval is the parsed value, assuming this is a Bool. If this is an
-	// Object or Array, whether the first element has been parsed yet.
+	// Object or Array, whether the first element has been parsed yet. If this
+	// is a Number, whether num holds the magnitude of the value.
 	boolval bool
 	// keynext (assuming this is an Object) is true if the next thing to read is
-	// a key, false if it's a value.
+	// a key, false if it's a value. If this is a Number, whether the literal is
+	// an integer (that is, it has no fraction or exponent).
 	keynext bool
+	// neg is true if this is a Number with a leading minus sign.
+	neg bool
 }
 
 // noescape prevents escape to the heap (unsafe, use with caution)
@@ -99,15 +134,39 @@ func feedq(buf *buffer) bool {
 }
 
 // feed feeds the buffer with the next chunk, assuming feedq is true. Cannot be
-// inlined, because of the call to Read().
+// inlined, because of the call to Read(). If there is no stream, the buffer
+// already held the entire input, so there is nothing left to read. If there is
+// a context, it is checked before each read, and once it is done its error takes
+// the place of the rest of the stream.
 func feed(buf *buffer) bool {
+	o := buf.opts
+	if o != nil && o.capture {
+		captureKey(buf, buf.erroffs)
+		o.capoffs = 0
+	}
 	buf.foffs += uint64(len(buf.data))
+	if buf.stream == nil {
+		buf.readerr = io.EOF
+		buf.erroffs = 0
+		buf.err = nil
+		buf.offs = 0
+		return false
+	}
 	var erroffs, readoffs int
 	var readerr error
 	for erroffs < len(buf.data) && readerr == nil {
+		if o != nil && o.ctx != nil {
+			readerr = o.ctx.Err()
+			if readerr != nil {
+				break
+			}
+		}
 		readoffs, readerr = buf.stream.Read(buf.data[erroffs:])
 		erroffs += readoffs
 	}
+	if o != nil && o.limits.MaxTotalBytes != 0 {
+		erroffs, readerr = limitTotal(buf, erroffs, readerr)
+	}
 	buf.readerr = readerr
 	buf.erroffs = uint32(erroffs)
 	buf.err = nil
@@ -115,6 +174,19 @@ func feed(buf *buffer) bool {
 	return false
 }
 
+// limitTotal applies MaxTotalBytes to the first n bytes of the buffer, which
+// have just been read, along with the error that ended the read. Anything past
+// the limit is cut off, and the error takes the place of the rest of the
+// stream, so it's reported when the parser reaches it.
+func limitTotal(buf *buffer, n int, err error) (int, error) {
+	lim := buf.opts.limits.MaxTotalBytes
+	start := buf.foffs - uint64(len(buf.data))
+	if start+uint64(n) > lim {
+		return int(lim - start), ErrTotalLimit{lim, lim, errPath(buf)}
+	}
+	return n, err
+}
+
 // next consumes the next byte from the input stream, storing it in the
 // lookahead. Can be inlined; separated from the above to make inlining
 // possible.
@@ -135,10 +207,14 @@ func foffs(buf *buffer) uint64 {
 
 // newErrUnexpected is a slightly easier way to make an ErrUnexpectedChar.
 func newErrUnexpected(buf *buffer, e ...byte) ErrUnexpectedChar {
+	var err ErrUnexpectedChar
 	if buf.err == io.EOF {
-		return newErrUnexpectedEOF(1+foffs(buf), e...)
+		err = newErrUnexpectedEOF(1+foffs(buf), e...)
+	} else {
+		err = newErrUnexpectedChar(foffs(buf), buf.curr, e...)
 	}
-	return newErrUnexpectedChar(foffs(buf), buf.curr, e...)
+	err.Path = errPath(buf)
+	return err
 }
 
 // skipSpace skips whitespace until the next significant character.
@@ -167,6 +243,7 @@ func readKeyword(buf *buffer) (JsonValue, error) {
 	var kw string
 	var typ = Bool
 	var val = false
+	offs := foffs(buf)
 	switch buf.curr {
 	case 'n':
 		kw = "null"
@@ -188,12 +265,13 @@ func readKeyword(buf *buffer) (JsonValue, error) {
 	}
 	_ = feedq(buf) && feed(buf)
 	next(buf)
-	return JsonValue{buf, 0, buf.depth + 1, typ, Complete, val, false}, nil
+	return JsonValue{buf, 0, offs, buf.depth + 1, typ, Complete, val, false, false}, nil
 }
 
 // readStream reads a string, array, or object from the stream.
 func readStream(buf *buffer) (JsonValue, error) {
 	var typ JsonType
+	offs := foffs(buf)
 	switch buf.curr {
 	case '"':
 		typ = String
@@ -204,8 +282,16 @@ func readStream(buf *buffer) (JsonValue, error) {
 	case '[':
 		typ = Array
 	}
+	if typ == String {
+		buf.strlen = 0
+	} else if o := buf.opts; o != nil && o.limits.MaxDepth != 0 &&
+		buf.depth >= o.limits.MaxDepth {
+		return JsonValue{}, ErrDepthLimit{offs, uint64(o.limits.MaxDepth), errPath(buf)}
+	} else {
+		buf.peeked = 0
+	}
 	buf.depth++
-	return JsonValue{buf, 0, buf.depth, typ, Working, false, typ == Object}, nil
+	return JsonValue{buf, 0, offs, buf.depth, typ, Working, false, typ == Object, false}, nil
 }
 
 // readValue reads any value from the stream.
@@ -221,24 +307,161 @@ func readValue(buf *buffer) (JsonValue, error) {
 		return readKeyword(buf)
 	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 		buf.depth++
-		return JsonValue{buf, 0, buf.depth, Number, Working, false, false}, nil
+		return JsonValue{buf, 0, foffs(buf), buf.depth, Number, Working, false, false, false}, nil
 	default:
 		return JsonValue{}, newErrUnexpected(buf, '{', '[', '"', 'n', 't', 'f',
 			'-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9')
 	}
 }
 
+// readTopLevel reads the top-level value from the stream. A Null or Bool value is
+// already complete once it has been read, so in strict mode the remainder of the
+// stream is checked immediately.
+func readTopLevel(buf *buffer) (JsonValue, error) {
+	val, err := readValue(buf)
+	if err == nil && buf.strict && buf.depth == 0 {
+		err = readEnd(buf)
+		if err != nil {
+			return JsonValue{}, err
+		}
+	}
+	return val, err
+}
+
 // Parse takes an io.Reader and begins to parse from it, returning a JsonValue.
 // This function also takes a size (in bytes) to use when creating the read
-// buffer.
+// buffer. To parse many streams without allocating, reuse a Parser with Reset.
 func Parse(r io.Reader, size int) (JsonValue, error) {
-	data := make([]byte, size)
-	buf := buffer{data, r, 0, nil, nil, uint32(size), 0, 0, 0, 0, 0, 0, 0, 0}
+	return ParseWithBuffer(r, make([]byte, size))
+}
+
+// ParseWithBuffer is like Parse, but uses the given slice as the read buffer
+// instead of allocating a new one, so that read buffers can be pooled. The
+// buffer is set up just as Parser.Reset sets up the Parser's own. The slice
+// must not be modified or reused until the parse is finished. The state of the
+// parse is still allocated on each call; to parse without allocating at all,
+// reuse a Parser with Reset.
+func ParseWithBuffer(r io.Reader, data []byte) (JsonValue, error) {
+	buf := streamBuffer(r, data)
+	_ = feedq(&buf) && feed(&buf)
+	next(&buf)
+	return readValue(&buf)
+}
+
+// ParseStrict is like Parse, but requires the stream to contain exactly one
+// top-level value, as with a Parser in strict mode. Once the value has been read
+// in its entirety, the remainder of the stream is checked, and any data other
+// than whitespace is reported as an error by the call that finished reading the
+// value, which leaves the value Incomplete.
+func ParseStrict(r io.Reader, size int) (JsonValue, error) {
+	buf := streamBuffer(r, make([]byte, size))
+	buf.strict = true
 	_ = feedq(&buf) && feed(&buf)
 	next(&buf)
+	return readTopLevel(&buf)
+}
+
+// ParseBytes begins to parse a JSON value that is already held in memory,
+// returning a JsonValue. The slice itself is used as the read buffer, so the
+// input is never copied or read from a stream. The slice must not be modified
+// until the parse is finished. If it is 4 GiB or more in length,
+// ErrInputTooLarge is returned. The state of the parse is still allocated on
+// each call; to parse many slices without allocating, or to apply Limits, use a
+// Parser with ResetBytes.
+func ParseBytes(data []byte) (JsonValue, error) {
+	if uint64(len(data)) > 1<<32-1 {
+		return JsonValue{}, ErrInputTooLarge
+	}
+	buf := bytesBuffer(data)
+	next(&buf)
 	return readValue(&buf)
 }
 
+// streamBuffer returns a read buffer that reads from the given stream into the
+// given slice. The slice starts out empty, so the first read feeds it.
+func streamBuffer(r io.Reader, data []byte) buffer {
+	return buffer{data: data, stream: r, offs: uint32(len(data))}
+}
+
+// bytesBuffer returns a read buffer that already holds the entire input.
+func bytesBuffer(data []byte) buffer {
+	return buffer{data: data, foffs: uint64(len(data)), readerr: io.EOF,
+		erroffs: uint32(len(data))}
+}
+
+// ParseContext is like Parse, but stops parsing once the given context is done.
+// The context is checked each time the read buffer is fed from the stream, so
+// it applies to every read made through the returned JsonValue and its
+// children, including long skips made by Close(). When the context is done, the
+// method that was reading returns ctx.Err() and the value becomes Incomplete. A
+// call to Read() on the underlying io.Reader that is already blocked can't be
+// interrupted; to bound those, use a reader with its own deadline. To combine
+// a context with limits or path tracking, use a Parser.
+func ParseContext(ctx context.Context, r io.Reader, size int) (JsonValue, error) {
+	buf := newOptBuffer(r, size)
+	buf.opts.ctx = ctx
+	_ = feedq(buf) && feed(buf)
+	next(buf)
+	return readValue(buf)
+}
+
+// optBuffer is a read buffer along with its options, so that a parse which
+// uses them can allocate both at once.
+type optBuffer struct {
+	buf  buffer
+	opts options
+}
+
+// newOptBuffer creates a read buffer of the given size for the given stream,
+// with options that are empty and ready to be filled in.
+func newOptBuffer(r io.Reader, size int) *buffer {
+	b := &optBuffer{}
+	b.buf = streamBuffer(r, make([]byte, size))
+	b.buf.opts = &b.opts
+	return &b.buf
+}
+
+// readEnd verifies that nothing but whitespace remains in the stream.
+func readEnd(buf *buffer) error {
+	_, err := skipSpace(buf)
+	if err != nil {
+		return err
+	}
+	if buf.err == io.EOF {
+		return nil
+	}
+	err1 := newErrUnexpected(buf)
+	err1.CustomMsg = "only whitespace is allowed after the top-level value"
+	return err1
+}
+
+// endStrict is called in strict mode, once the top-level value has been read in
+// its entirety. It checks the remainder of the stream for trailing data.
+func endStrict(data *JsonValue) error {
+	err := readEnd(data.buffer)
+	if err != nil {
+		data.Status = Incomplete
+	}
+	return err
+}
+
+// Finish completes the parse of a top-level value. If the value hasn't been
+// read in its entirety, the remainder is discarded as with Close(). Finish then
+// verifies that nothing but whitespace follows the value in the stream, and
+// returns an error if any other data is found.
+func (data *JsonValue) Finish() error {
+	if data.depth != 1 {
+		return ErrNotTopLevel
+	}
+	if data.buffer.depth != 0 {
+		err := data.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return readEnd(data.buffer)
+}
+
 // escapemap is a mapping from escape sequences to escaped character values.
 var escapemap = [...]byte{
 	'"':  '"',
@@ -255,12 +478,35 @@ var escapemap = [...]byte{
 // values). Reading from this interface provides the value of the string.
 func (data *JsonValue) Read(b []byte) (int, error) {
 	if data.Type != String {
-		return 0, newErrTypeMismatch(data.Type, String)
+		return 0, newErrWrongType(data, String)
 	} else if data.Status == Complete {
 		return 0, io.EOF
 	} else if data.Status != Working {
-		return 0, ErrIncomplete
+		return 0, withPath(data, ErrIncomplete)
 	}
+	var lim uint64
+	if o := data.buffer.opts; o != nil {
+		lim = o.limits.MaxStringBytes
+	}
+	if lim != 0 && uint64(len(b)) > lim-data.buffer.strlen {
+		// Read one byte more than the limit allows. If that byte is filled, the
+		// string is too long.
+		rem := int(lim - data.buffer.strlen)
+		i, err := readString(data, b[:rem+1])
+		if i > rem {
+			data.Status = Incomplete
+			return rem, ErrStringLimit{data.offs, lim, errPath(data.buffer)}
+		}
+		data.buffer.strlen += uint64(i)
+		return i, err
+	}
+	i, err := readString(data, b)
+	data.buffer.strlen += uint64(i)
+	return i, err
+}
+
+// readString reads the contents of a String into the given slice.
+func readString(data *JsonValue, b []byte) (int, error) {
 	i := 0
 	if data.buffer.escapes > 0 {
 		i = streamEscape(data.buffer, b, 0)
@@ -273,10 +519,18 @@ func (data *JsonValue) Read(b []byte) (int, error) {
 		c := data.buffer.curr
 		switch {
 		case c == '"':
+			if o := data.buffer.opts; o != nil && o.capture {
+				endKey(data.buffer, data.buffer.offs-1)
+			}
 			_ = feedq(data.buffer) && feed(data.buffer)
 			next(data.buffer)
 			data.Status = Complete
 			data.buffer.depth--
+			if data.buffer.strict && data.buffer.depth == 0 {
+				if err := endStrict(data); err != nil {
+					return i, err
+				}
+			}
 			return i, io.EOF
 		case c == '\\':
 			_ = feedq(data.buffer) && feed(data.buffer)
@@ -441,98 +695,328 @@ func streamEscape(buf *buffer, b []byte, i int) int {
 	return i
 }
 
-// readInt is a special case of readNumber, and is designed to parse integers.
-// This parses integers in about half the time compared to strconv.ParseInt().
-func readInt(data *JsonValue, sl []byte) error {
-	var val int64
-	neg := false
-	idx := 0
-	if sl[0] == '-' {
-		if len(sl) == 1 {
-			data.Status = Incomplete
-			return newErrUnexpected(data.buffer,
-				'0', '1', '2', '3', '4', '5', '6', '7', '8', '9')
+// Number scanner states. These track progress through the JSON number grammar,
+// which is -?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)? as a regular expression.
+const (
+	numStart   byte = iota // before the literal
+	numMinus               // after the leading minus sign
+	numZero                // after a leading zero
+	numInt                 // within the integer part
+	numDot                 // after the decimal point
+	numFrac                // within the fractional part
+	numExp                 // after the exponent marker
+	numExpSign             // after the sign of the exponent
+	numExpInt              // within the exponent
+	numStates
+	numStop = 0xFE // the byte is not part of the literal
+	numBad  = 0xFF // the byte is part of the literal, but not valid here
+)
+
+// numtrans is the transition table for the number scanner. It maps a state and
+// the next byte in the stream to the next state.
+var numtrans = func() (t [numStates][256]byte) {
+	for i := range t {
+		s := byte(i)
+		for c := range t[s] {
+			t[s][c] = numStop
 		}
-		neg = true
-		idx = 1
-	}
-	for ; idx < len(sl); idx++ {
-		if sl[idx] <= '9' && sl[idx] >= '0' {
-			val = 10*val + int64(sl[idx]-'0')
-		} else {
-			data.Status = Incomplete
-			offs := foffs(data.buffer) + uint64(1+idx-len(sl))
-			return newErrUnexpectedChar(offs, sl[idx],
-				'0', '1', '2', '3', '4', '5', '6', '7', '8', '9')
+		for _, c := range "+-.eE0123456789" {
+			t[s][c] = numBad
+		}
+		switch s {
+		case numStart, numMinus:
+			t[s]['0'] = numZero
+			for c := '1'; c <= '9'; c++ {
+				t[s][c] = numInt
+			}
+			if s == numStart {
+				t[s]['-'] = numMinus
+			}
+		case numZero, numInt:
+			t[s]['.'] = numDot
+			t[s]['e'] = numExp
+			t[s]['E'] = numExp
+			if s == numInt {
+				for c := '0'; c <= '9'; c++ {
+					t[s][c] = numInt
+				}
+			}
+		case numDot, numFrac:
+			for c := '0'; c <= '9'; c++ {
+				t[s][c] = numFrac
+			}
+			if s == numFrac {
+				t[s]['e'] = numExp
+				t[s]['E'] = numExp
+			}
+		case numExp, numExpSign, numExpInt:
+			for c := '0'; c <= '9'; c++ {
+				t[s][c] = numExpInt
+			}
+			if s == numExp {
+				t[s]['+'] = numExpSign
+				t[s]['-'] = numExpSign
+			}
 		}
 	}
-	if neg {
-		val = -val
-	}
-	data.numval = float64(val)
-	data.Status = Complete
-	data.buffer.depth--
-	return nil
+	return
+}()
+
+// numexpect lists the bytes that can follow each state of the number scanner,
+// for use in error messages.
+var numexpect = [numStates][]byte{
+	numStart:   []byte("-0123456789"),
+	numMinus:   []byte("0123456789"),
+	numZero:    []byte(".Ee"),
+	numInt:     []byte(".0123456789Ee"),
+	numDot:     []byte("0123456789"),
+	numFrac:    []byte("0123456789Ee"),
+	numExp:     []byte("+-0123456789"),
+	numExpSign: []byte("0123456789"),
+	numExpInt:  []byte("0123456789"),
 }
 
-// readNumber reads a numeric value from the stream.
-func readNumber(data *JsonValue) error {
-	var b [32]byte
-	sl := b[:0]
-	simple := true
-L:
+// numEnd is true if the number scanner can end on the given state.
+func numEnd(state byte) bool {
+	return state == numZero || state == numInt ||
+		state == numFrac || state == numExpInt
+}
+
+// scanNumber consumes a numeric literal from the stream, and verifies that it
+// conforms to the JSON number grammar. If keep is true, the literal is appended
+// to the slice, and its digits are counted against the limit. The final state
+// of the scanner is returned.
+func scanNumber(data *JsonValue, sl []byte, keep bool) ([]byte, byte, error) {
+	state := numStart
+	var lim uint32
+	if o := data.buffer.opts; o != nil {
+		lim = o.limits.MaxNumberDigits
+	}
+	var digits uint32
 	for {
 		if data.buffer.err != nil {
 			if data.buffer.err == io.EOF {
-				break L
+				break
 			}
 			data.Status = Incomplete
-			return data.buffer.err
+			return sl, state, data.buffer.err
 		}
-		switch data.buffer.curr {
-		case '+', '.', 'e', 'E':
-			simple = false
-			fallthrough
-		case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			sl = append(sl, data.buffer.curr)
-			_ = feedq(data.buffer) && feed(data.buffer)
-			next(data.buffer)
-		default:
-			break L
+		c := data.buffer.curr
+		st := numtrans[state][c]
+		if st == numStop {
+			break
+		} else if st == numBad {
+			data.Status = Incomplete
+			return sl, state, newErrUnexpected(data.buffer, numexpect[state]...)
 		}
+		state = st
+		if keep {
+			if lim != 0 && c >= '0' && c <= '9' {
+				digits++
+				if digits > lim {
+					data.Status = Incomplete
+					return sl, state, ErrNumberLimit{data.offs, uint64(lim), errPath(data.buffer)}
+				}
+			}
+			sl = append(sl, c)
+		}
+		_ = feedq(data.buffer) && feed(data.buffer)
+		next(data.buffer)
+	}
+	if !numEnd(state) {
+		data.Status = Incomplete
+		return sl, state, newErrUnexpected(data.buffer, numexpect[state]...)
+	}
+	return sl, state, nil
+}
+
+// readInt is a special case of readNumber, and is designed to parse integers.
+// This parses integers in about half the time compared to strconv.ParseInt().
+// The literal must already have been validated by scanNumber. Returns false if
+// the magnitude of the integer doesn't fit in 64 bits.
+func readInt(data *JsonValue, sl []byte) bool {
+	var val uint64
+	idx := 0
+	if sl[0] == '-' {
+		idx = 1
 	}
-	if simple && len(sl) < 19 {
-		return readInt(data, sl)
+	if len(sl)-idx > 20 {
+		return false
+	} else if len(sl)-idx == 20 {
+		// Only a 20 digit integer can overflow, and only on the last digit.
+		for ; idx < len(sl)-1; idx++ {
+			val = 10*val + uint64(sl[idx]-'0')
+		}
+		d := uint64(sl[idx] - '0')
+		if val > (math.MaxUint64-d)/10 {
+			return false
+		}
+		val = 10*val + d
+	} else {
+		for ; idx < len(sl); idx++ {
+			val = 10*val + uint64(sl[idx]-'0')
+		}
+	}
+	data.num = val
+	return true
+}
+
+// readNumber reads a numeric value from the stream. The literal is kept in the
+// buffer until the next Number is read, so that it can be retrieved later.
+func readNumber(data *JsonValue) error {
+	sl := data.buffer.numlit[:0]
+	if sl == nil {
+		sl = data.buffer.numbuf[:0]
 	}
-	// strconv.ParseFloat takes a string, but we only have a []byte.
-	// Converting to string requires a new alloc and a copy, plus an escape
-	// to heap for the underlying array. This line does an unsafe cast and
-	// sidesteps escape analysis, avoiding those expensive extra steps.
-	f, err := strconv.ParseFloat(*(*string)(noescape(unsafe.Pointer(&sl))), 64)
+	sl, state, err := scanNumber(data, sl, true)
 	if err != nil {
-		data.Status = Incomplete
 		return err
 	}
-	data.numval = f
+	data.buffer.numlit = sl
+	data.buffer.numoffs = data.offs
+	data.neg = sl[0] == '-'
+	data.keynext = state == numZero || state == numInt
+	data.boolval = data.keynext && readInt(data, sl)
+	if !data.boolval {
+		// strconv.ParseFloat takes a string, but we only have a []byte.
+		// Converting to string requires a new alloc and a copy, plus an escape
+		// to heap for the underlying array. This line does an unsafe cast and
+		// sidesteps escape analysis, avoiding those expensive extra steps.
+		// The literal is known to be valid, so the only possible error is
+		// ErrRange, in which case the result is infinite. The value is still
+		// usable through ValueNumRaw() or ValueBigFloat().
+		f, _ := strconv.ParseFloat(*(*string)(noescape(unsafe.Pointer(&sl))), 64)
+		data.num = math.Float64bits(f)
+	}
 	data.Status = Complete
 	data.buffer.depth--
+	if data.buffer.strict && data.buffer.depth == 0 {
+		return endStrict(data)
+	}
 	return nil
 }
 
-// ValueNum returns the value of a Number.
+// ValueNum returns the value of a Number. If the value is too large in
+// magnitude to be represented by a float64, it is returned as an infinity, along
+// with an ErrNumRange.
 func (data *JsonValue) ValueNum() (float64, error) {
 	if data.Type != Number {
-		return 0, newErrTypeMismatch(data.Type, Number)
-	} else if data.Status == Complete {
-		return data.numval, nil
-	} else if data.Status != Working {
-		return 0, ErrIncomplete
+		return 0, newErrWrongType(data, Number)
+	} else if data.Status == Working {
+		err := readNumber(data)
+		if err != nil {
+			return 0, err
+		}
+	} else if data.Status != Complete {
+		return 0, withPath(data, ErrIncomplete)
+	}
+	f := math.Float64frombits(data.num)
+	if data.boolval {
+		f = float64(data.num)
+		if data.neg {
+			f = -f
+		}
+	}
+	if math.IsInf(f, 0) {
+		return f, ErrNumRange{"float64", false, data.Path()}
 	}
-	err := readNumber(data)
+	return f, nil
+}
+
+// valueInt reads a Number if it hasn't been read yet, and makes sure it is an
+// integer that fits in 64 bits.
+func valueInt(data *JsonValue, typ string) error {
+	if data.Type != Number {
+		return newErrWrongType(data, Number)
+	} else if data.Status == Working {
+		err := readNumber(data)
+		if err != nil {
+			return err
+		}
+	} else if data.Status != Complete {
+		return withPath(data, ErrIncomplete)
+	}
+	if !data.keynext {
+		return ErrNumRange{typ, true, data.Path()}
+	} else if !data.boolval {
+		return ErrNumRange{typ, false, data.Path()}
+	}
+	return nil
+}
+
+// ValueInt64 returns the value of a Number as a signed 64 bit integer. The
+// literal is parsed exactly, so this can be used for integers too large to be
+// represented precisely by ValueNum(). If the literal has a fraction or an
+// exponent, or if it doesn't fit in an int64, an ErrNumRange is returned.
+func (data *JsonValue) ValueInt64() (int64, error) {
+	err := valueInt(data, "int64")
 	if err != nil {
 		return 0, err
 	}
-	return data.numval, nil
+	if data.neg {
+		if data.num > 1<<63 {
+			return 0, ErrNumRange{"int64", false, data.Path()}
+		}
+		return int64(-data.num), nil
+	} else if data.num > math.MaxInt64 {
+		return 0, ErrNumRange{"int64", false, data.Path()}
+	}
+	return int64(data.num), nil
+}
+
+// ValueUint64 returns the value of a Number as an unsigned 64 bit integer. The
+// literal is parsed exactly, so this can be used for integers too large to be
+// represented precisely by ValueNum(). If the literal has a fraction or an
+// exponent, or if it doesn't fit in a uint64, an ErrNumRange is returned.
+func (data *JsonValue) ValueUint64() (uint64, error) {
+	err := valueInt(data, "uint64")
+	if err != nil {
+		return 0, err
+	}
+	if data.neg && data.num != 0 {
+		return 0, ErrNumRange{"uint64", false, data.Path()}
+	}
+	return data.num, nil
+}
+
+// numLiteral reads a Number if it hasn't been read yet, and returns its literal
+// text, or nil if the literal is no longer held in the buffer.
+func numLiteral(data *JsonValue) ([]byte, error) {
+	if data.Type != Number {
+		return nil, newErrWrongType(data, Number)
+	} else if data.Status == Working {
+		err := readNumber(data)
+		if err != nil {
+			return nil, err
+		}
+	} else if data.Status != Complete {
+		return nil, withPath(data, ErrIncomplete)
+	}
+	if data.buffer.numoffs == data.offs && len(data.buffer.numlit) > 0 {
+		return data.buffer.numlit, nil
+	}
+	return nil, nil
+}
+
+// ValueNumRaw appends the literal text of a Number to the given slice, exactly
+// as it appears in the stream, and returns the extended slice. This can be used
+// alongside ValueNum() and the other Number methods. The literal is retained
+// until the next Number is read from the stream; after that, only an integer
+// that fits in 64 bits can be reproduced, and ErrLiteralDiscarded is returned
+// for any other literal.
+func (data *JsonValue) ValueNumRaw(dst []byte) ([]byte, error) {
+	lit, err := numLiteral(data)
+	if err != nil {
+		return dst, err
+	} else if lit != nil {
+		return append(dst, lit...), nil
+	} else if data.keynext && data.boolval {
+		if data.neg {
+			dst = append(dst, '-')
+		}
+		return strconv.AppendUint(dst, data.num, 10), nil
+	}
+	return dst, withPath(data, ErrLiteralDiscarded)
 }
 
 // ValueBool returns the value of a Bool.
@@ -540,12 +1024,28 @@ func (data *JsonValue) ValueBool() (bool, error) {
 	if data.Type == Bool {
 		return data.boolval, nil
 	}
-	return false, newErrTypeMismatch(data.Type, Bool)
+	return false, newErrWrongType(data, Bool)
+}
+
+// ValueString reads the rest of a String into memory, and returns it. Unlike
+// Read(), this allocates, so it's best kept to values that are actually needed.
+func (data *JsonValue) ValueString() (string, error) {
+	if data.Type != String {
+		return "", newErrWrongType(data, String)
+	}
+	return readText(data)
 }
 
 // readNext reads the next key or value from an Object or Array, respectively.
 // This is a shared function, because the logic is the same in both cases.
 func readNext(data *JsonValue, open byte, close byte) error {
+	if data.buffer.peeked == data.depth {
+		// Peek has already consumed the separator.
+		data.buffer.peeked = 0
+		if data.boolval || data.buffer.curr != close {
+			return nil
+		}
+	}
 	c, err := skipSpace(data.buffer)
 	if err != nil {
 		data.Status = Incomplete
@@ -556,6 +1056,11 @@ func readNext(data *JsonValue, open byte, close byte) error {
 		next(data.buffer)
 		data.Status = Complete
 		data.buffer.depth--
+		if data.buffer.strict && data.buffer.depth == 0 {
+			if err := endStrict(data); err != nil {
+				return err
+			}
+		}
 		return EndOfValue
 	}
 	var expect byte = ','
@@ -579,6 +1084,11 @@ func readNext(data *JsonValue, open byte, close byte) error {
 			next(data.buffer)
 			data.Status = Complete
 			data.buffer.depth--
+			if data.buffer.strict && data.buffer.depth == 0 {
+				if err := endStrict(data); err != nil {
+					return err
+				}
+			}
 			return EndOfValue
 		}
 	}
@@ -591,13 +1101,16 @@ func readNext(data *JsonValue, open byte, close byte) error {
 // EndOfValue error is returned.
 func (data *JsonValue) NextKey() (JsonValue, error) {
 	if data.Type != Object {
-		return JsonValue{}, newErrTypeMismatch(data.Type, Object)
+		return JsonValue{}, newErrWrongType(data, Object)
 	} else if data.Status == Complete {
 		return JsonValue{}, EndOfValue
 	} else if data.Status != Working {
-		return JsonValue{}, ErrIncomplete
+		return JsonValue{}, withPath(data, ErrIncomplete)
 	} else if data.depth != data.buffer.depth {
-		return JsonValue{}, ErrWorkingChild
+		err := settle(data)
+		if err != nil {
+			return JsonValue{}, err
+		}
 	}
 	if data.keynext == false {
 		val, err := objectNextValue(data)
@@ -624,7 +1137,17 @@ func (data *JsonValue) NextKey() (JsonValue, error) {
 		data.Status = Incomplete
 		return JsonValue{}, newErrUnexpected(data.buffer, '"')
 	}
+	data.num++
+	if o := data.buffer.opts; o != nil {
+		if lim := o.limits.MaxMembers; lim != 0 && data.num > lim {
+			data.Status = Incomplete
+			return JsonValue{}, ErrMemberLimit{foffs(data.buffer), lim, errPath(data.buffer)}
+		}
+	}
 	val, _ := readStream(data.buffer)
+	if o := data.buffer.opts; o != nil && o.path != nil {
+		pushKey(data.buffer, data.depth)
+	}
 	data.boolval = true
 	data.keynext = false
 	return val, nil
@@ -633,7 +1156,10 @@ func (data *JsonValue) NextKey() (JsonValue, error) {
 // arrayNextValue reads the next value from an Object.
 func objectNextValue(data *JsonValue) (JsonValue, error) {
 	if data.depth != data.buffer.depth {
-		return JsonValue{}, ErrWorkingChild
+		err := settle(data)
+		if err != nil {
+			return JsonValue{}, err
+		}
 	}
 	if data.keynext == true {
 		key, err := data.NextKey()
@@ -647,17 +1173,22 @@ func objectNextValue(data *JsonValue) (JsonValue, error) {
 			return JsonValue{}, err
 		}
 	}
-	c, err := skipSpace(data.buffer)
-	if err != nil {
-		data.Status = Incomplete
-		return JsonValue{}, err
-	}
-	if c != ':' {
-		data.Status = Incomplete
-		return JsonValue{}, newErrUnexpected(data.buffer, ':')
+	if data.buffer.peeked == data.depth {
+		// Peek has already consumed the colon.
+		data.buffer.peeked = 0
+	} else {
+		c, err := skipSpace(data.buffer)
+		if err != nil {
+			data.Status = Incomplete
+			return JsonValue{}, err
+		}
+		if c != ':' {
+			data.Status = Incomplete
+			return JsonValue{}, newErrUnexpected(data.buffer, ':')
+		}
+		_ = feedq(data.buffer) && feed(data.buffer)
+		next(data.buffer)
 	}
-	_ = feedq(data.buffer) && feed(data.buffer)
-	next(data.buffer)
 	val, err1 := readValue(data.buffer)
 	if err1 != nil {
 		data.Status = Incomplete
@@ -670,12 +1201,19 @@ func objectNextValue(data *JsonValue) (JsonValue, error) {
 // arrayNextValue reads the next value from an Array.
 func arrayNextValue(data *JsonValue) (JsonValue, error) {
 	if data.depth != data.buffer.depth {
-		return JsonValue{}, ErrWorkingChild
+		err := settle(data)
+		if err != nil {
+			return JsonValue{}, err
+		}
 	}
 	err := readNext(data, '[', ']')
 	if err != nil {
 		return JsonValue{}, err
 	}
+	if o := data.buffer.opts; o != nil && o.path != nil {
+		pushIndex(data.buffer, data.depth, data.num)
+	}
+	data.num++
 	val, err1 := readValue(data.buffer)
 	if err1 != nil {
 		data.Status = Incomplete
@@ -696,11 +1234,99 @@ func (data *JsonValue) NextValue() (JsonValue, error) {
 	} else if data.Type == Object && data.Status == Working {
 		return objectNextValue(data)
 	} else if data.Type != Array && data.Type != Object {
-		return JsonValue{}, newErrTypeMismatch(data.Type, Array, Object)
+		return JsonValue{}, newErrWrongType(data, Array, Object)
 	} else if data.Status == Complete {
 		return JsonValue{}, EndOfValue
 	}
-	return JsonValue{}, ErrIncomplete
+	return JsonValue{}, withPath(data, ErrIncomplete)
+}
+
+// Peek looks ahead to the next element of an Object or Array, without reading
+// it, and returns its type. If there are no more elements, false is returned.
+// If the next part of an Object to parse is a key, the type is always String,
+// since it's the key that is next; otherwise, it's the type of the value. Only
+// whitespace and separators are consumed, so the element can still be read as
+// usual with NextKey() or NextValue(). If the stream has a syntax error where
+// the element should be, the error is returned, and it is returned again when
+// the element is read.
+func (data *JsonValue) Peek() (JsonType, bool, error) {
+	if data.Type != Array && data.Type != Object {
+		return Null, false, newErrWrongType(data, Array, Object)
+	} else if data.Status == Complete {
+		return Null, false, nil
+	} else if data.Status != Working {
+		return Null, false, withPath(data, ErrIncomplete)
+	} else if data.depth != data.buffer.depth {
+		err := settle(data)
+		if err != nil {
+			return Null, false, err
+		}
+	}
+	buf := data.buffer
+	if buf.peeked != data.depth {
+		c, err := skipSpace(buf)
+		if err != nil {
+			return Null, false, err
+		}
+		open, close := byte('['), byte(']')
+		if data.Type == Object {
+			open, close = '{', '}'
+		}
+		if data.Type == Object && !data.keynext {
+			if c != ':' {
+				return Null, false, newErrUnexpected(buf, ':')
+			}
+		} else if c == close {
+			return Null, false, nil
+		} else if !data.boolval && c != open {
+			return Null, false, newErrUnexpected(buf, open, close)
+		} else if data.boolval && c != ',' {
+			return Null, false, newErrUnexpected(buf, ',', close)
+		}
+		_ = feedq(buf) && feed(buf)
+		next(buf)
+		buf.peeked = data.depth
+	}
+	c, err := skipSpace(buf)
+	if err != nil {
+		return Null, false, err
+	}
+	if data.Type == Object && data.keynext {
+		if c == '"' {
+			return String, true, nil
+		} else if c == '}' && !data.boolval {
+			return Null, false, nil
+		}
+		return Null, false, newErrUnexpected(buf, '"')
+	}
+	switch c {
+	case '{':
+		return Object, true, nil
+	case '[':
+		return Array, true, nil
+	case '"':
+		return String, true, nil
+	case 'n':
+		return Null, true, nil
+	case 't', 'f':
+		return Bool, true, nil
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return Number, true, nil
+	case ']':
+		if !data.boolval {
+			return Null, false, nil
+		}
+	}
+	return Null, false, newErrUnexpected(buf, '{', '[', '"', 'n', 't', 'f',
+		'-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9')
+}
+
+// More reports whether an Object or Array has another element to be read. If an
+// error occurs while looking ahead, true is returned, so that the error is
+// reported by the next call to NextKey() or NextValue().
+func (data *JsonValue) More() bool {
+	_, ok, err := data.Peek()
+	return ok || err != nil
 }
 
 // Close implements the io.Closer interface for JsonValues. Closing a JsonValue
@@ -710,14 +1336,17 @@ func (data *JsonValue) Close() error {
 	if data.Status == Complete {
 		return nil
 	} else if data.Status != Working {
-		return ErrIncomplete
+		return withPath(data, ErrIncomplete)
 	} else if data.depth != data.buffer.depth {
-		return ErrWorkingChild
+		err := settle(data)
+		if err != nil {
+			return err
+		}
 	}
 	if data.Type == Number {
 		return closeNumber(data)
 	}
-	if data.boolval == false {
+	if data.boolval == false && data.buffer.peeked != data.depth {
 		if data.Type == Object && data.buffer.curr == '{' ||
 			data.Type == Array && data.buffer.curr == '[' {
 			_ = feedq(data.buffer) && feed(data.buffer)
@@ -727,8 +1356,30 @@ func (data *JsonValue) Close() error {
 	return closeObjectArray(data)
 }
 
-// closeNumber is a special case for Close, and works on Numbers.
+// consumed reports whether the child of an Object or Array that was handed to
+// a callback (or to the body of a loop) has been read in its entirety, whether
+// directly or through a copy. Close() can't tell this from a copy of the child,
+// which looks just like a sibling opened after it; but nothing else is read
+// from the parent while the callback runs, so it can be told from the parent.
+func consumed(data *JsonValue) bool {
+	return data.buffer.depth == data.depth
+}
+
+// closeNumber is a special case for Close, and works on Numbers. In strict mode,
+// the literal is checked against the JSON number grammar as it is discarded.
 func closeNumber(data *JsonValue) error {
+	if data.buffer.strict {
+		_, _, err := scanNumber(data, nil, false)
+		if err != nil {
+			return err
+		}
+		data.Status = Incomplete
+		data.buffer.depth--
+		if data.buffer.depth == 0 {
+			return endStrict(data)
+		}
+		return nil
+	}
 	for {
 		if data.buffer.err != nil {
 			if data.buffer.err == io.EOF {
@@ -759,35 +1410,96 @@ func closeNumber(data *JsonValue) error {
 	}
 }
 
+// orphan records that the Objects and Arrays from depth lo to depth hi have been
+// left open by Seek. The caller has no JsonValue for any of them, so they are
+// discarded by settle once the value below them is finished.
+func orphan(buf *buffer, lo uint32, hi uint32) {
+	if buf.opts == nil {
+		buf.opts = &options{}
+	}
+	o := buf.opts
+	if o.orphans == nil {
+		o.orphans = o.orphbuf[:0]
+	}
+	o.orphans = append(o.orphans, lo, hi)
+}
+
+// settle is called when a method is used on an Object or Array while a deeper
+// value is still open. If the only values open below it are orphans left by
+// Seek, they are discarded so the method can proceed. Otherwise, a child is
+// still being read, and ErrWorkingChild is returned.
+func settle(data *JsonValue) error {
+	o := data.buffer.opts
+	if o == nil {
+		return ErrWorkingChild
+	}
+	n := len(o.orphans)
+	if n == 0 || o.orphans[n-2] != data.depth+1 || o.orphans[n-1] != data.buffer.depth {
+		return ErrWorkingChild
+	}
+	o.orphans = o.orphans[:n-2]
+	err := skip(data.buffer, false, int(data.buffer.depth-data.depth), nil)
+	if err != nil {
+		data.Status = Incomplete
+		return err
+	}
+	data.buffer.depth = data.depth
+	return nil
+}
+
 // closeObjectArray is the general case for Close, and works on Objects and
 // Arrays.
 func closeObjectArray(data *JsonValue) error {
-	instr := data.Type == String
-	depth := 0
+	err := skip(data.buffer, data.Type == String, 1, nil)
+	if err != nil {
+		data.Status = Incomplete
+		return err
+	}
+	data.Status = Complete
+	data.buffer.depth--
+	if data.buffer.strict && data.buffer.depth == 0 {
+		return endStrict(data)
+	}
+	return nil
+}
+
+// skip discards the remainder of a value from the stream, without parsing it.
+// If str is true, the value is a String. Otherwise, the stream is positioned
+// within the given number of nested Objects or Arrays, and all of them are
+// discarded; if that number is zero, the stream is positioned at the opening
+// brace or bracket of the value. If raw is not nil, the discarded bytes are
+// passed to it.
+func skip(buf *buffer, str bool, levels int, raw *rawSink) error {
+	instr := str
+	depth := levels - 1
+	start := buf.offs - 1
 	for {
-		if data.buffer.err != nil {
-			data.Status = Incomplete
-			if data.buffer.err == io.EOF {
-				err := newErrUnexpected(data.buffer)
+		if buf.err != nil {
+			if buf.err == io.EOF {
+				err := newErrUnexpected(buf)
 				err.CustomMsg = "premature EOF while attempting to close value"
 				return err
 			}
-			return data.buffer.err
+			return buf.err
 		}
-		i := data.buffer.offs - 1
+		i := buf.offs - 1
 	InStr:
 		if instr {
-			for i < data.buffer.erroffs {
-				switch data.buffer.data[i] {
+			for i < buf.erroffs {
+				switch buf.data[i] {
 				case '\\':
 					i++
 				case '"':
-					if data.Type == String {
-						data.buffer.offs = i + 1
-						_ = feedq(data.buffer) && feed(data.buffer)
-						next(data.buffer)
-						data.Status = Complete
-						data.buffer.depth--
+					if str {
+						if o := buf.opts; o != nil && o.capture {
+							endKey(buf, i)
+						}
+						if raw != nil {
+							raw.put(buf.data[start : i+1])
+						}
+						buf.offs = i + 1
+						_ = feedq(buf) && feed(buf)
+						next(buf)
 						return nil
 					}
 					instr = false
@@ -797,18 +1509,19 @@ func closeObjectArray(data *JsonValue) error {
 				i++
 			}
 		} else {
-			for i < data.buffer.erroffs {
-				switch data.buffer.data[i] {
+			for i < buf.erroffs {
+				switch buf.data[i] {
 				case '{', '[':
 					depth++
 				case '}', ']':
 					depth--
 					if depth < 0 {
-						data.buffer.offs = i + 1
-						_ = feedq(data.buffer) && feed(data.buffer)
-						next(data.buffer)
-						data.Status = Complete
-						data.buffer.depth--
+						if raw != nil {
+							raw.put(buf.data[start : i+1])
+						}
+						buf.offs = i + 1
+						_ = feedq(buf) && feed(buf)
+						next(buf)
 						return nil
 					}
 				case '"':
@@ -819,13 +1532,147 @@ func closeObjectArray(data *JsonValue) error {
 				i++
 			}
 		}
-		data.buffer.offs = data.buffer.erroffs
-		if feedq(data.buffer) {
-			feed(data.buffer)
-			data.buffer.offs = i - uint32(len(data.buffer.data))
+		if raw != nil {
+			raw.put(buf.data[start:buf.erroffs])
 		}
-		next(data.buffer)
+		start = 0
+		buf.offs = buf.erroffs
+		if feedq(buf) {
+			feed(buf)
+			buf.offs = i - uint32(len(buf.data))
+			if feedq(buf) {
+				// An escaped character took up the entire chunk.
+				if raw != nil {
+					raw.put(buf.data[:buf.erroffs])
+				}
+				feed(buf)
+			}
+		}
+		next(buf)
+	}
+}
+
+// rawSink receives the bytes discarded by skip. They are written to w, or if w
+// is nil, appended to buf. Once a write fails, the rest of the bytes are dropped.
+type rawSink struct {
+	buf []byte
+	w   io.Writer
+	n   int64
+	err error
+}
+
+// rawQuote is the opening quote of a String, which skip never sees.
+var rawQuote = []byte{'"'}
+
+// The literal text of Nulls and Bools, for WriteRawTo and appendRaw.
+var (
+	rawNull  = []byte("null")
+	rawTrue  = []byte("true")
+	rawFalse = []byte("false")
+)
+
+// rawKeyword returns the literal text of a Null or Bool.
+func rawKeyword(data *JsonValue) []byte {
+	switch {
+	case data.Type == Null:
+		return rawNull
+	case data.boolval:
+		return rawTrue
+	}
+	return rawFalse
+}
+
+// put passes a slice of the stream to the sink.
+func (raw *rawSink) put(b []byte) {
+	if raw.w == nil {
+		raw.buf = append(raw.buf, b...)
+		return
+	} else if raw.err != nil {
+		return
+	}
+	n, err := raw.w.Write(b)
+	raw.n += int64(n)
+	raw.err = err
+}
+
+// appendRaw appends the text of a value to the given slice, exactly as it
+// appears in the stream, consuming the value in the process. The value must not
+// have been read from yet.
+func appendRaw(dst []byte, data *JsonValue) ([]byte, error) {
+	switch data.Type {
+	case Null, Bool:
+		return append(dst, rawKeyword(data)...), nil
+	case Number:
+		return data.ValueNumRaw(dst)
+	}
+	raw := rawSink{buf: dst}
+	err := copyRaw(&raw, data)
+	return raw.buf, err
+}
+
+// copyRaw passes the text of a String, Object, or Array to the given sink,
+// exactly as it appears in the stream, consuming the value in the process.
+func copyRaw(raw *rawSink, data *JsonValue) error {
+	switch {
+	case data.Status == Complete:
+		return ErrPartiallyRead
+	case data.Status != Working:
+		return withPath(data, ErrIncomplete)
+	case data.depth != data.buffer.depth:
+		return ErrWorkingChild
+	case data.Type == String && data.buffer.strlen > 0,
+		data.Type != String && (data.boolval || data.buffer.peeked == data.depth):
+		return ErrPartiallyRead
+	}
+	levels := 0
+	if data.Type == String {
+		raw.put(rawQuote)
+		levels = 1
+	}
+	err := skip(data.buffer, data.Type == String, levels, raw)
+	if err != nil {
+		data.Status = Incomplete
+		return err
 	}
+	data.Status = Complete
+	data.buffer.depth--
+	if data.buffer.strict && data.buffer.depth == 0 {
+		return endStrict(data)
+	}
+	return nil
+}
+
+// WriteRawTo consumes a value, as Close() does, but copies its text to the given
+// writer exactly as it appears in the stream, including escapes and whitespace.
+// This is useful for forwarding part of a document verbatim. The value must not
+// have been read from yet (Numbers excepted, as with ValueNumRaw()); otherwise,
+// ErrPartiallyRead is returned. The number of bytes written is returned. If a
+// write fails, the value is still consumed, and the write error is returned.
+func (data *JsonValue) WriteRawTo(w io.Writer) (int64, error) {
+	var lit []byte
+	switch data.Type {
+	case Null, Bool:
+		lit = rawKeyword(data)
+	case Number:
+		var err error
+		lit, err = numLiteral(data)
+		if err == nil && lit == nil {
+			// The literal was discarded, but it may still be reproducible.
+			lit, err = data.ValueNumRaw(nil)
+		}
+		if err != nil {
+			return 0, err
+		}
+	default:
+		raw := rawSink{w: w}
+		err := copyRaw(&raw, data)
+		if err == nil {
+			err = raw.err
+		}
+		return raw.n, err
+	}
+	n, err := w.Write(lit)
+	return int64(n), err
 }
 
 // simpleSort sorts the inputs in-place. Usually this is a short list, and may
@@ -854,7 +1701,7 @@ func compareRead(data *JsonValue, vals []string) (string, bool, error) {
 		for {
 			if len(vals[x]) >= z && vals[x][y:z] == string(buf[:l]) {
 				break
-			} else if x+1 >= len(vals) || vals[x][:y] != vals[x+1][:y] {
+			} else if x+1 >= len(vals) || len(vals[x+1]) < y || vals[x][:y] != vals[x+1][:y] {
 				return "", false, data.Close()
 			}
 			x++
@@ -890,6 +1737,12 @@ func (data *JsonValue) FindKey(keys ...string) (string, JsonValue, bool, error)
 		return "", JsonValue{}, false, ErrNoParamsSpecified
 	}
 	simpleSort(keys)
+	return findKey(data, keys, false)
+}
+
+// findKey does the work of FindKey and FindKeyFold. The keys must be sorted to
+// suit the comparison.
+func findKey(data *JsonValue, keys []string, fold bool) (string, JsonValue, bool, error) {
 	for {
 		key, err := data.NextKey()
 		if err == EndOfValue {
@@ -897,7 +1750,14 @@ func (data *JsonValue) FindKey(keys ...string) (string, JsonValue, bool, error)
 		} else if err != nil {
 			return "", JsonValue{}, false, err
 		}
-		k, match, err1 := compareRead(&key, keys)
+		var k string
+		var match bool
+		var err1 error
+		if fold {
+			k, match, err1 = compareFoldRead(&key, keys)
+		} else {
+			k, match, err1 = compareRead(&key, keys)
+		}
 		if err1 != nil {
 			return "", JsonValue{}, false, err1
 		} else if match {
@@ -909,3 +1769,53 @@ func (data *JsonValue) FindKey(keys ...string) (string, JsonValue, bool, error)
 		}
 	}
 }
+
+// EachKey is a helper function, designed to extract several values from an
+// Object in a single pass, whatever order their keys appear in. It reads the
+// object to the end, and for each key that matches one of the provided keys,
+// it calls fn with the index of that key in the slice and the associated value.
+// Every other key/value pair is discarded. Once fn returns, anything left of the
+// value is discarded as well. If fn returns an error, EachKey stops and returns
+// it, leaving the rest of the Object to be read. Unlike FindKey, the keys slice
+// is not modified. The value must not be used once fn returns, but fn may
+// consume it through a copy, such as one passed to Unmarshal() or Walk().
+func (data *JsonValue) EachKey(keys []string, fn func(idx int, v *JsonValue) error) error {
+	if len(keys) <= 0 {
+		return ErrNoParamsSpecified
+	}
+	var arr [16]string
+	sorted := append(arr[:0], keys...)
+	simpleSort(sorted)
+	var val JsonValue
+	for {
+		key, err := data.NextKey()
+		if err == EndOfValue {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		k, match, err := compareRead(&key, sorted)
+		if err != nil {
+			return err
+		} else if !match {
+			continue
+		}
+		idx := 0
+		for keys[idx] != k {
+			idx++
+		}
+		val, err = data.NextValue()
+		if err != nil {
+			return err
+		}
+		err = fn(idx, &val)
+		if !consumed(data) {
+			if err1 := val.Close(); err == nil {
+				err = err1
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
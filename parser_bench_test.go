@@ -60,3 +60,89 @@ func BenchmarkFloatClosing(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkIntParsingReuse(b *testing.B) {
+	json := "[0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9]"
+	r := strings.NewReader(json)
+	p := NewParser(r, 16)
+	for i := 0; i < b.N; i++ {
+		r.Reset(json)
+		p.Reset(r)
+		array, err := p.Next()
+		var elem JsonValue
+		for err != EndOfValue {
+			elem, err = array.NextValue()
+			if err == nil {
+				elem.ValueNum()
+			}
+		}
+	}
+}
+
+func BenchmarkIntParsingBytes(b *testing.B) {
+	json := []byte("[0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9]")
+	for i := 0; i < b.N; i++ {
+		array, err := ParseBytes(json)
+		var elem JsonValue
+		for err != EndOfValue {
+			elem, err = array.NextValue()
+			if err == nil {
+				elem.ValueNum()
+			}
+		}
+	}
+}
+
+func BenchmarkFloatParsingBytes(b *testing.B) {
+	json := []byte("[0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9]")
+	for i := 0; i < b.N; i++ {
+		array, err := ParseBytes(json)
+		var elem JsonValue
+		for err != EndOfValue {
+			elem, err = array.NextValue()
+			if err == nil {
+				elem.ValueNum()
+			}
+		}
+	}
+}
+
+func BenchmarkIntClosingBytes(b *testing.B) {
+	json := []byte("[0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9]")
+	for i := 0; i < b.N; i++ {
+		array, err := ParseBytes(json)
+		var elem JsonValue
+		for err != EndOfValue {
+			elem, err = array.NextValue()
+			elem.Close()
+		}
+	}
+}
+
+func BenchmarkFloatClosingBytes(b *testing.B) {
+	json := []byte("[0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9,0.1,2.3,4.5,6.7,8.9]")
+	for i := 0; i < b.N; i++ {
+		array, err := ParseBytes(json)
+		var elem JsonValue
+		for err != EndOfValue {
+			elem, err = array.NextValue()
+			elem.Close()
+		}
+	}
+}
+
+func BenchmarkIntParsingBytesReuse(b *testing.B) {
+	json := []byte("[0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9,0,1,2,3,4,5,6,7,8,9]")
+	p := NewParser(nil, 16)
+	for i := 0; i < b.N; i++ {
+		p.ResetBytes(json)
+		array, err := p.Next()
+		var elem JsonValue
+		for err != EndOfValue {
+			elem, err = array.NextValue()
+			if err == nil {
+				elem.ValueNum()
+			}
+		}
+	}
+}
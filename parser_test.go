@@ -1,6 +1,7 @@
 package jsonmuncher
 
 import (
+	"context"
 	"errors"
 	"io"
 	"strconv"
@@ -167,6 +168,134 @@ func TestNumericParsing(t *testing.T) {
 		"15", vn, en)
 }
 
+func TestIntegerParsing(t *testing.T) {
+	json := "[9007199254740993, -9223372036854775808, 9223372036854775808, 18446744073709551615, 18446744073709551616, 1.5, 1e3, -1, -0]"
+	r := strings.NewReader(json)
+	v1, _ := Parse(r, 16)
+	v2, _ := v1.NextValue()
+	vi, ei := v2.ValueInt64()
+	assert(t, vi != 9007199254740993 || ei != nil,
+		"1", vi, ei)
+	vn, en := v2.ValueNum()
+	assert(t, vn != 9007199254740992 || en != nil,
+		"2", vn, en)
+	vu, eu := v2.ValueUint64()
+	assert(t, vu != 9007199254740993 || eu != nil,
+		"3", vu, eu)
+	v2, _ = v1.NextValue()
+	vi, ei = v2.ValueInt64()
+	assert(t, vi != -9223372036854775808 || ei != nil,
+		"4", vi, ei)
+	vu, eu = v2.ValueUint64()
+	assert(t, vu != 0 || eu == nil || eu.Error() != "Number is out of range for uint64",
+		"5", vu, eu)
+	v2, _ = v1.NextValue()
+	vi, ei = v2.ValueInt64()
+	assert(t, vi != 0 || ei != (ErrNumRange{"int64", false, ""}),
+		"6", vi, ei)
+	vu, eu = v2.ValueUint64()
+	assert(t, vu != 9223372036854775808 || eu != nil,
+		"7", vu, eu)
+	v2, _ = v1.NextValue()
+	vu, eu = v2.ValueUint64()
+	assert(t, vu != 18446744073709551615 || eu != nil,
+		"8", vu, eu)
+	v2, _ = v1.NextValue()
+	vu, eu = v2.ValueUint64()
+	assert(t, vu != 0 || eu != (ErrNumRange{"uint64", false, ""}),
+		"9", vu, eu)
+	vn, en = v2.ValueNum()
+	assert(t, vn != 18446744073709551616 || en != nil,
+		"10", vn, en)
+	v2, _ = v1.NextValue()
+	vi, ei = v2.ValueInt64()
+	assert(t, vi != 0 || ei == nil || ei.Error() != "Number is not an integer, cannot convert to int64",
+		"11", vi, ei)
+	vn, en = v2.ValueNum()
+	assert(t, vn != 1.5 || en != nil,
+		"12", vn, en)
+	v2, _ = v1.NextValue()
+	vu, eu = v2.ValueUint64()
+	assert(t, vu != 0 || eu != (ErrNumRange{"uint64", true, ""}),
+		"13", vu, eu)
+	v2, _ = v1.NextValue()
+	vi, ei = v2.ValueInt64()
+	assert(t, vi != -1 || ei != nil,
+		"14", vi, ei)
+	v2, _ = v1.NextValue()
+	vi, ei = v2.ValueInt64()
+	assert(t, vi != 0 || ei != nil,
+		"15", vi, ei)
+	vu, eu = v2.ValueUint64()
+	assert(t, vu != 0 || eu != nil,
+		"16", vu, eu)
+	_, e2 := v1.NextValue()
+	assert(t, e2 != EndOfValue,
+		"17", e2)
+	_, ei = v1.ValueInt64()
+	assert(t, ei == nil || ei.Error() != "Method cannot be called on type Array, only on Number",
+		"18", ei)
+	p := NewParser(r, 16)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset("[18446744073709551615, -42]")
+		p.Reset(r)
+		v1, _ := p.Next()
+		v2, _ := v1.NextValue()
+		v2.ValueUint64()
+		v2, _ = v1.NextValue()
+		v2.ValueInt64()
+		v1.Close()
+	})
+	assert(t, allocs != 0,
+		"19", allocs)
+}
+
+func TestRawNumbers(t *testing.T) {
+	json := "[1.50, 12345678901234567890123456789012345678901234567890, -0, 1E-07, 42]"
+	var buf [64]byte
+	r := strings.NewReader(json)
+	v1, _ := Parse(r, 16)
+	v2, _ := v1.NextValue()
+	raw, er := v2.ValueNumRaw(buf[:0])
+	assert(t, string(raw) != "1.50" || er != nil,
+		"1", string(raw), er)
+	vn, en := v2.ValueNum()
+	assert(t, vn != 1.5 || en != nil,
+		"2", vn, en)
+	raw, er = v2.ValueNumRaw(raw)
+	assert(t, string(raw) != "1.501.50" || er != nil,
+		"3", string(raw), er)
+	v3, _ := v1.NextValue()
+	vn, en = v3.ValueNum()
+	assert(t, vn != 1.2345678901234567e49 || en != nil,
+		"4", vn, en)
+	raw, er = v3.ValueNumRaw(buf[:0])
+	assert(t, string(raw) != "12345678901234567890123456789012345678901234567890" || er != nil,
+		"5", string(raw), er)
+	raw, er = v2.ValueNumRaw(buf[:0])
+	assert(t, len(raw) != 0 || er != ErrLiteralDiscarded,
+		"6", string(raw), er)
+	v2, _ = v1.NextValue()
+	v2.ValueInt64()
+	v3, _ = v1.NextValue()
+	raw, er = v3.ValueNumRaw(buf[:0])
+	assert(t, string(raw) != "1E-07" || er != nil,
+		"7", string(raw), er)
+	raw, er = v2.ValueNumRaw(buf[:0])
+	assert(t, string(raw) != "-0" || er != nil,
+		"8", string(raw), er)
+	v2, _ = v1.NextValue()
+	e2 := v2.Close()
+	assert(t, e2 != nil,
+		"9", e2)
+	raw, er = v2.ValueNumRaw(buf[:0])
+	assert(t, len(raw) != 0 || er != ErrIncomplete,
+		"10", string(raw), er)
+	_, er = v1.ValueNumRaw(buf[:0])
+	assert(t, er == nil || er.Error() != "Method cannot be called on type Array, only on Number",
+		"11", er)
+}
+
 func TestStringParsing(t *testing.T) {
 	json := "[\"\", \" \\// \\\\ \\n \\t \\b \\r \\f \\\" \", \" (\\u256f\\u00b0\\u25a1\\u00b0\\uff09\\u256f\\ufe35 \\u253b\\u2501\\u253b \"]"
 	var buf [48]byte
@@ -197,6 +326,27 @@ func TestStringParsing(t *testing.T) {
 		"8", e2)
 }
 
+func TestValueString(t *testing.T) {
+	json := "[\"a \\\"long\\\" string \\u00b0 that spans several reads\", \"partial\", 1]"
+	for size := 1; size <= len(json); size++ {
+		v1, _ := Parse(strings.NewReader(json), size)
+		v2, _ := v1.NextValue()
+		s, e := v2.ValueString()
+		assert(t, e != nil || s != "a \"long\" string ° that spans several reads",
+			"1", size, s, e)
+		v2, _ = v1.NextValue()
+		var buf [3]byte
+		v2.Read(buf[:])
+		s, e = v2.ValueString()
+		assert(t, e != nil || s != "tial",
+			"2", size, s, e)
+		v2, _ = v1.NextValue()
+		s, e = v2.ValueString()
+		assert(t, e == nil || e.Error() != "Method cannot be called on type Number, only on String",
+			"3", size, s, e)
+	}
+}
+
 func TestEscapeBuffer(t *testing.T) {
 	json := "\"[\\uD83E\\uDDF8]\""
 	var buf [16]byte
@@ -291,6 +441,11 @@ func TestCompareHelper(t *testing.T) {
 	sk, mk, ek = v2.Compare("match1", "match2")
 	assert(t, sk != "" || mk != false || ek != nil,
 		"8", sk, mk, ek)
+	// The next candidate is shorter than what has been read so far.
+	v2, _ = Parse(strings.NewReader(`"organization_namX"`), 16)
+	sk, mk, ek = v2.Compare("organization_name", "title")
+	assert(t, sk != "" || mk != false || ek != nil,
+		"9", sk, mk, ek)
 }
 
 func TestFindKeyHelper(t *testing.T) {
@@ -319,6 +474,377 @@ func TestFindKeyHelper(t *testing.T) {
 		"7", sk, mk, ek)
 }
 
+func TestEachKeyHelper(t *testing.T) {
+	json := "{\"slug\":\"a\",\"skip\":[1,{}],\"id\":7,\"ids\":[],\"name\":\"n\",\"id\":8}"
+	r := strings.NewReader(json)
+	v1, _ := Parse(r, 4)
+	keys := []string{"id", "slug", "missing"}
+	var ids []float64
+	var slug string
+	var buf [8]byte
+	e := v1.EachKey(keys, func(idx int, v *JsonValue) error {
+		switch idx {
+		case 0:
+			n, err := v.ValueNum()
+			ids = append(ids, n)
+			return err
+		case 1:
+			s, _ := v.Read(buf[:])
+			slug = string(buf[:s])
+		default:
+			t.Fatal("1", idx)
+		}
+		return nil
+	})
+	assert(t, e != nil || v1.Status != Complete,
+		"2", e, v1.Status)
+	assert(t, len(ids) != 2 || ids[0] != 7 || ids[1] != 8 || slug != "a",
+		"3", ids, slug)
+	assert(t, keys[0] != "id" || keys[1] != "slug" || keys[2] != "missing",
+		"4", keys)
+	e = v1.EachKey(nil, nil)
+	assert(t, e != ErrNoParamsSpecified,
+		"5", e)
+	r.Reset(json)
+	v1, _ = Parse(r, 4)
+	stop := errors.New("stop")
+	e = v1.EachKey(keys, func(idx int, v *JsonValue) error {
+		return stop
+	})
+	assert(t, e != stop,
+		"6", e)
+	sk, vk, mk, ek := v1.FindKey("ids")
+	assert(t, sk != "ids" || vk.Type != Array || mk != true || ek != nil,
+		"7", sk, vk.Type, mk, ek)
+	p := NewParser(r, 4)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(json)
+		p.Reset(r)
+		v1, _ := p.Next()
+		v1.EachKey(keys, func(idx int, v *JsonValue) error {
+			return nil
+		})
+	})
+	// One for the value passed to fn.
+	assert(t, allocs > 1,
+		"8", allocs)
+}
+
+func TestParseStrict(t *testing.T) {
+	var buf [8]byte
+	v1, e1 := ParseStrict(strings.NewReader("{\"a\":[1]} \n"), 4)
+	assert(t, v1.Type != Object || e1 != nil,
+		"1", v1.Type, e1)
+	e1 = v1.Close()
+	assert(t, v1.Status != Complete || e1 != nil,
+		"2", v1.Status, e1)
+	v1, _ = ParseStrict(strings.NewReader("{\"a\":[1]} {}"), 4)
+	e1 = v1.Close()
+	assert(t, v1.Status != Incomplete || e1 == nil || e1.Error() != "Unexpected '{' at file offset 10: only whitespace is allowed after the top-level value",
+		"3", v1.Status, e1)
+	v1, _ = ParseStrict(strings.NewReader("\"str\"x"), 16)
+	_, e1 = v1.Read(buf[:])
+	assert(t, e1 == nil || e1.Error() != "Unexpected 'x' at file offset 5: only whitespace is allowed after the top-level value",
+		"4", e1)
+	_, e1 = ParseStrict(strings.NewReader("null,"), 16)
+	assert(t, e1 == nil || e1.Error() != "Unexpected ',' at file offset 4: only whitespace is allowed after the top-level value",
+		"5", e1)
+}
+
+func TestParseWithBuffer(t *testing.T) {
+	var data [4]byte
+	r := strings.NewReader("[true, \"long string\"]")
+	v1, e1 := ParseWithBuffer(r, data[:])
+	assert(t, v1.Type != Array || e1 != nil,
+		"1", v1.Type, e1)
+	v2, e2 := v1.NextValue()
+	assert(t, v2.Type != Bool || e2 != nil,
+		"2", v2.Type, e2)
+	v2, e2 = v1.NextValue()
+	assert(t, v2.Type != String || e2 != nil,
+		"3", v2.Type, e2)
+	sk, mk, ek := v2.Compare("long string")
+	assert(t, sk != "long string" || mk != true || ek != nil,
+		"4", sk, mk, ek)
+	_, e2 = v1.NextValue()
+	assert(t, e2 != EndOfValue,
+		"5", e2)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset("[true, \"long string\"]")
+		v1, _ := ParseWithBuffer(r, data[:])
+		v1.Close()
+	})
+	// At most one, for the state of the parse.
+	assert(t, allocs > 1,
+		"6", allocs)
+}
+
+func TestParseBytes(t *testing.T) {
+	json := []byte("{\"a\" : [1.5, \"x\\u00b0y\", {\"b\":[true]}], \"c\":null}")
+	var buf [8]byte
+	v1, e1 := ParseBytes(json)
+	assert(t, v1.Type != Object || e1 != nil,
+		"1", v1.Type, e1)
+	_, v2, m2, e2 := v1.FindKey("a")
+	assert(t, v2.Type != Array || m2 != true || e2 != nil,
+		"2", v2.Type, m2, e2)
+	v3, e3 := v2.NextValue()
+	vn, en := v3.ValueNum()
+	assert(t, vn != 1.5 || en != nil,
+		"3", vn, en)
+	v3, e3 = v2.NextValue()
+	s, eof := v3.Read(buf[:])
+	assert(t, eof != io.EOF || string(buf[:s]) != "x°y",
+		"4", string(buf[:s]), eof)
+	v3, e3 = v2.NextValue()
+	assert(t, v3.Type != Object || e3 != nil,
+		"5", v3.Type, e3)
+	e3 = v3.Close()
+	assert(t, e3 != nil,
+		"6", e3)
+	_, e3 = v2.NextValue()
+	assert(t, e3 != EndOfValue,
+		"7", e3)
+	_, v2, m2, e2 = v1.FindKey("c")
+	assert(t, v2.Type != Null || m2 != true || e2 != nil,
+		"8", v2.Type, m2, e2)
+	e1 = v1.Finish()
+	assert(t, v1.Status != Complete || e1 != nil,
+		"9", v1.Status, e1)
+	assert(t, string(json) != "{\"a\" : [1.5, \"x\\u00b0y\", {\"b\":[true]}], \"c\":null}",
+		"10", string(json))
+	v1, _ = ParseBytes([]byte("[1, {\"a\": [2"))
+	e1 = v1.Close()
+	assert(t, e1 == nil || e1.Error() != "Unexpected EOF at file offset 12: premature EOF while attempting to close value",
+		"11", e1)
+	_, e1 = ParseBytes(nil)
+	assert(t, e1 == nil || e1.Error() != "Unexpected EOF at file offset 0, expected one of '{', '[', '\"', 'n', 't', 'f', '-', '0'-'9'",
+		"12", e1)
+}
+
+// cancelReader cancels a context once a given number of reads have been made.
+type cancelReader struct {
+	r      io.Reader
+	reads  int
+	cancel context.CancelFunc
+}
+
+func (c *cancelReader) Read(b []byte) (int, error) {
+	c.reads--
+	if c.reads == 0 {
+		c.cancel()
+	}
+	return c.r.Read(b)
+}
+
+func TestParseContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &cancelReader{strings.NewReader("[1, 2, 3, 4, 5, 6, 7, 8]"), 2, cancel}
+	v1, e := ParseContext(ctx, r, 4)
+	assert(t, v1.Type != Array || e != nil,
+		"1", v1.Type, e)
+	v2, _ := v1.NextValue()
+	n, e := v2.ValueNum()
+	assert(t, n != 1 || e != nil,
+		"2", n, e)
+	v2, _ = v1.NextValue()
+	n, e = v2.ValueNum()
+	assert(t, n != 2 || e != nil,
+		"3", n, e)
+	v2, _ = v1.NextValue()
+	_, e = v2.ValueNum()
+	assert(t, e != context.Canceled || v2.Status != Incomplete,
+		"4", e, v2.Status)
+	ctx, cancel = context.WithCancel(context.Background())
+	r = &cancelReader{strings.NewReader("[\"abcdefghijklmnopqrstuvwxyz\"]"), 3, cancel}
+	v1, _ = ParseContext(ctx, r, 4)
+	e = v1.Close()
+	assert(t, e != context.Canceled || v1.Status != Incomplete,
+		"5", e, v1.Status)
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	_, e = ParseContext(ctx, strings.NewReader("[]"), 4)
+	assert(t, e != context.DeadlineExceeded,
+		"6", e)
+	v1, e = ParseContext(context.Background(), strings.NewReader("{\"a\":[1,2]}"), 4)
+	assert(t, v1.Type != Object || e != nil,
+		"7", v1.Type, e)
+	e = v1.Close()
+	assert(t, e != nil,
+		"8", e)
+}
+
+func TestWriteRawTo(t *testing.T) {
+	json := "{\"a\": 1.50, \"company\": { \"name\" : \"A\\\\\\\"\\u00e9\",\n\t\"tags\": [\"x]\", {}] }, \"b\": true}"
+	company := "{ \"name\" : \"A\\\\\\\"\\u00e9\",\n\t\"tags\": [\"x]\", {}] }"
+	for size := 1; size <= len(json); size++ {
+		v1, _ := Parse(strings.NewReader(json), size)
+		var bld strings.Builder
+		for {
+			k, e := v1.NextKey()
+			if e == EndOfValue {
+				break
+			}
+			k.Close()
+			v2, _ := v1.NextValue()
+			l := bld.Len()
+			n, e := v2.WriteRawTo(&bld)
+			assert(t, e != nil || n != int64(bld.Len()-l),
+				"1", size, n, e)
+			bld.WriteByte('|')
+		}
+		assert(t, bld.String() != "1.50|"+company+"|true|",
+			"2", size, bld.String())
+	}
+	v1, _ := Parse(strings.NewReader("[\"abc\", null, [1]]"), 4)
+	v2, _ := v1.NextValue()
+	var b [1]byte
+	v2.Read(b[:])
+	_, e := v2.WriteRawTo(io.Discard)
+	assert(t, e != ErrPartiallyRead,
+		"3", e)
+	v2.Close()
+	v2, _ = v1.NextValue()
+	var bld strings.Builder
+	n, e := v2.WriteRawTo(&bld)
+	assert(t, n != 4 || e != nil || bld.String() != "null",
+		"4", n, e, bld.String())
+	v2, _ = v1.NextValue()
+	v2.NextValue()
+	_, e = v2.WriteRawTo(io.Discard)
+	assert(t, e != ErrWorkingChild,
+		"5", e)
+	v1, _ = Parse(strings.NewReader("[[1, 2, 3], 4]"), 4)
+	v2, _ = v1.NextValue()
+	n, e = v2.WriteRawTo(failWriter{})
+	assert(t, n != 0 || e != errFailWrite,
+		"6", n, e)
+	v2, _ = v1.NextValue()
+	m, e := v2.ValueNum()
+	assert(t, m != 4 || e != nil,
+		"7", m, e)
+}
+
+// failWriter is an io.Writer that always fails.
+type failWriter struct{}
+
+var errFailWrite = errors.New("write failed")
+
+func (failWriter) Write(b []byte) (int, error) {
+	return 0, errFailWrite
+}
+
+func TestPeek(t *testing.T) {
+	json := "[ {\"a\" : [ ] , \"b\":\"x\", \"c\":{}}, [[1]], \"s\", null, true, -2 ]"
+	for size := 1; size <= len(json); size++ {
+		v1, _ := Parse(strings.NewReader(json), size)
+		var types []JsonType
+		for v1.More() {
+			typ, ok, e := v1.Peek()
+			assert(t, !ok || e != nil,
+				"1", size, ok, e)
+			types = append(types, typ)
+			v2, e := v1.NextValue()
+			assert(t, e != nil || v2.Type != typ,
+				"2", size, e, v2.Type, typ)
+			if typ == Object {
+				typ, ok, e = v2.Peek()
+				assert(t, typ != String || !ok || e != nil,
+					"3", size, typ, ok, e)
+				v3, _ := v2.NextKey()
+				v3.Close()
+				typ, ok, e = v2.Peek()
+				assert(t, typ != Array || !ok || e != nil,
+					"4", size, typ, ok, e)
+				v3, _ = v2.NextValue()
+				assert(t, v3.More(),
+					"5", size)
+				v3.Close()
+				v3, _ = v2.NextValue()
+				assert(t, v3.Type != String,
+					"6", size, v3.Type)
+				v3.Close()
+				v3, _ = v2.NextValue()
+				assert(t, v3.More(),
+					"7", size)
+				v3.Close()
+				assert(t, v2.More(),
+					"8", size)
+			} else if typ == Array {
+				typ, ok, e = v2.Peek()
+				assert(t, typ != Array || !ok || e != nil,
+					"9", size, typ, ok, e)
+			}
+			e = v2.Close()
+			assert(t, e != nil,
+				"10", size, e)
+		}
+		assert(t, len(types) != 6 || types[0] != Object || types[1] != Array ||
+			types[2] != String || types[3] != Null || types[4] != Bool || types[5] != Number,
+			"11", size, types)
+		_, e := v1.NextValue()
+		assert(t, e != EndOfValue,
+			"12", size, e)
+	}
+	v1, _ := Parse(strings.NewReader("[1, ]"), 4)
+	v2, _ := v1.NextValue()
+	v2.Close()
+	_, ok, e := v1.Peek()
+	assert(t, ok || e == nil || e.Error() != "Unexpected ']' at file offset 4, expected one of '{', '[', '\"', 'n', 't', 'f', '-', '0'-'9'",
+		"13", ok, e)
+	assert(t, !v1.More(),
+		"14")
+	_, e = v1.NextValue()
+	assert(t, e == nil || e.Error() != "Unexpected ']' at file offset 4, expected one of '{', '[', '\"', 'n', 't', 'f', '-', '0'-'9'",
+		"15", e)
+	v1, _ = Parse(strings.NewReader("{\"a\": 1, 2}"), 4)
+	v2, _ = v1.NextValue()
+	v2.Close()
+	_, _, e = v1.Peek()
+	assert(t, e == nil || e.Error() != "Unexpected '2' at file offset 9, expected '\"'",
+		"16", e)
+	v1, _ = Parse(strings.NewReader("\"s\""), 4)
+	_, _, e = v1.Peek()
+	assert(t, e == nil || e.Error() != "Method cannot be called on type String, only on Array or Object",
+		"17", e)
+}
+
+func TestFinish(t *testing.T) {
+	r := strings.NewReader("[1, {\"a\":2}]  \n")
+	v, _ := Parse(r, 4)
+	e := v.Finish()
+	assert(t, v.Status != Complete || e != nil,
+		"1", v.Status, e)
+	r = strings.NewReader("{\"a\":1} garbage")
+	v, _ = Parse(r, 16)
+	e = v.Finish()
+	assert(t, e == nil || e.Error() != "Unexpected 'g' at file offset 8: only whitespace is allowed after the top-level value",
+		"2", e)
+	r = strings.NewReader("truex")
+	v, _ = Parse(r, 16)
+	e = v.Finish()
+	assert(t, e == nil || e.Error() != "Unexpected 'x' at file offset 4: only whitespace is allowed after the top-level value",
+		"3", e)
+	r = strings.NewReader("12 3")
+	v, _ = Parse(r, 16)
+	e = v.Finish()
+	assert(t, e == nil || e.Error() != "Unexpected '3' at file offset 3: only whitespace is allowed after the top-level value",
+		"4", e)
+	r = strings.NewReader("[[]]")
+	v, _ = Parse(r, 16)
+	a, _ := v.NextValue()
+	e = a.Finish()
+	assert(t, e != ErrNotTopLevel,
+		"5", e)
+	e = v.Finish()
+	assert(t, e != ErrWorkingChild,
+		"6", e)
+	a.Close()
+	e = v.Finish()
+	assert(t, e != nil,
+		"7", e)
+}
+
 // failure states
 
 var eofErrors = []string{
@@ -471,18 +997,79 @@ func TestBadNumberErrors(t *testing.T) {
 	r = strings.NewReader("1-2")
 	x, _ = Parse(r, 16)
 	_, e1 = x.ValueNum()
-	assert(t, e1 == nil || e1.Error() != "Unexpected '-' at file offset 1, expected one of '0'-'9'",
+	assert(t, e1 == nil || e1.Error() != "Unexpected '-' at file offset 1, expected one of '.', '0'-'9', 'E', 'e'",
 		"2", e1)
 	r = strings.NewReader("1.e")
 	x, _ = Parse(r, 16)
 	_, e1 = x.ValueNum()
-	assert(t, e1 == nil || e1.Error() != "strconv.ParseFloat: parsing \"1.e\": invalid syntax",
+	assert(t, e1 == nil || e1.Error() != "Unexpected 'e' at file offset 2, expected one of '0'-'9'",
 		"3", e1)
 	_, e1 = x.ValueNum()
 	assert(t, e1 == nil || e1.Error() != "Status incomplete denotes failed read",
 		"4", e1)
 }
 
+var badNumbers = []struct{ json, err string }{
+	{"01", "Unexpected '1' at file offset 1, expected one of '.', 'E', 'e'"},
+	{"-007", "Unexpected '0' at file offset 2, expected one of '.', 'E', 'e'"},
+	{"1.", "Unexpected EOF at file offset 2, expected one of '0'-'9'"},
+	{"1.x", "Unexpected 'x' at file offset 2, expected one of '0'-'9'"},
+	{"-", "Unexpected EOF at file offset 1, expected one of '0'-'9'"},
+	{"--1", "Unexpected '-' at file offset 1, expected one of '0'-'9'"},
+	{"-.5", "Unexpected '.' at file offset 1, expected one of '0'-'9'"},
+	{"1.5.2", "Unexpected '.' at file offset 3, expected one of '0'-'9', 'E', 'e'"},
+	{"1e", "Unexpected EOF at file offset 2, expected one of '+', '-', '0'-'9'"},
+	{"1e+", "Unexpected EOF at file offset 3, expected one of '0'-'9'"},
+	{"1e+-2", "Unexpected '-' at file offset 3, expected one of '0'-'9'"},
+	{"1E5e2", "Unexpected 'e' at file offset 3, expected one of '0'-'9'"},
+	{"2+2", "Unexpected '+' at file offset 1, expected one of '.', '0'-'9', 'E', 'e'"},
+	{"1.0E", "Unexpected EOF at file offset 4, expected one of '+', '-', '0'-'9'"},
+}
+
+var goodNumbers = []struct {
+	json string
+	num  float64
+}{
+	{"0", 0},
+	{"-0", 0},
+	{"0.5", 0.5},
+	{"-0.25e1", -2.5},
+	{"10E-1", 1},
+	{"1e+2", 100},
+	{"123456789012345678901", 123456789012345678901},
+}
+
+func TestNumberGrammar(t *testing.T) {
+	for i, n := range badNumbers {
+		x, _ := Parse(strings.NewReader(n.json), 2)
+		_, e1 := x.ValueNum()
+		assert(t, e1 == nil || e1.Error() != n.err || x.Status != Incomplete,
+			"1", i, n.json, e1)
+		x, _ = Parse(strings.NewReader(n.json), 2)
+		e1 = x.Close()
+		assert(t, e1 != nil,
+			"2", i, n.json, e1)
+		p := NewParser(strings.NewReader(n.json), 2)
+		p.Strict = true
+		x, _ = p.Next()
+		e1 = x.Close()
+		assert(t, e1 == nil || e1.Error() != n.err,
+			"3", i, n.json, e1)
+	}
+	for i, n := range goodNumbers {
+		x, _ := Parse(strings.NewReader(n.json), 2)
+		vn, e1 := x.ValueNum()
+		assert(t, vn != n.num || e1 != nil || x.Status != Complete,
+			"4", i, n.json, vn, e1)
+		p := NewParser(strings.NewReader(n.json+" "), 2)
+		p.Strict = true
+		x, _ = p.Next()
+		e1 = x.Close()
+		assert(t, e1 != nil,
+			"5", i, n.json, e1)
+	}
+}
+
 func TestBadStringErrors(t *testing.T) {
 	r := strings.NewReader("\"\\w\"")
 	var buf [8]byte
@@ -605,7 +1192,7 @@ func TestStreamErrors(t *testing.T) {
 	f := func(json string, offs int) (JsonValue, error) {
 		r := strings.NewReader(json)
 		data := make([]byte, 16)
-		buf := buffer{data, r, 0, nil, nil, uint32(16), 0, 0, 0, 0, 0, 0, 0, 0}
+		buf := buffer{data: data, stream: r, offs: uint32(16)}
 		_ = feedq(&buf) && feed(&buf)
 		buf.readerr = streamerr
 		buf.erroffs = uint32(offs)
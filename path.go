@@ -0,0 +1,731 @@
+package jsonmuncher
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Kinds of path segments.
+const (
+	segName   byte = iota // a child, by key
+	segWild               // every child
+	segIndex              // an element, by index
+	segSlice              // a range of elements
+	segFilter             // every child that passes a filter
+)
+
+// pathSeg is a single compiled segment of a Path.
+type pathSeg struct {
+	// kind is the kind of segment this is.
+	kind byte
+	// desc is true if this is a recursive descent segment, which applies to
+	// every descendant, rather than only to direct children.
+	desc bool
+	// name is the key selected by a segName segment.
+	name string
+	// start, end, and step are the bounds of a segSlice segment, or (in the
+	// case of start) the index selected by a segIndex segment. An end of -1
+	// means the slice is unbounded.
+	start, end, step int64
+	// filter is the filter applied by a segFilter segment.
+	filter pathFilter
+}
+
+// pathFilter is a filter expression, which tests a scalar member of a child.
+type pathFilter struct {
+	// key is the member being tested.
+	key string
+	// exists is true if the filter only checks that the member exists.
+	exists bool
+	// typ is the type of the value the member is compared against.
+	typ JsonType
+	// str, num, and b hold the value the member is compared against.
+	str string
+	num float64
+	b   bool
+}
+
+// Path is a compiled JSONPath expression, for use with Query(). A Path can be
+// used any number of times, including concurrently.
+type Path struct {
+	expr string
+	segs []pathSeg
+}
+
+// maxPathSegs is the maximum number of segments in a Path. The matcher tracks
+// its states in a bitmask, with one bit to spare for the final state.
+const maxPathSegs = 63
+
+// CompilePath compiles a JSONPath expression. The supported subset of JSONPath
+// consists of the root ($), children (.name or ['name']), wildcards (.* or
+// [*]), recursive descent (..name, ..*, or ..[...]), indices ([3]), slices
+// ([start:end:step]), and filters that test a member of a child for existence
+// ([?(@.name)]) or for equality with a string, number, boolean, or null
+// ([?(@.name == 'value')]). Negative indices can't be resolved in a single
+// forward pass, and so are not supported; neither is a filter as the last
+// segment, since the Object it selects would have to be matched before it is
+// read.
+func CompilePath(expr string) (*Path, error) {
+	c := pathCompiler{expr: expr}
+	if !c.eat('$') {
+		return nil, c.fail("expected '$'")
+	}
+	var start int
+	for c.pos < len(expr) {
+		var seg pathSeg
+		var err error
+		start = c.pos
+		if c.eat('.') {
+			seg.desc = c.eat('.')
+			if c.eat('*') {
+				seg.kind = segWild
+			} else if seg.desc && c.peek('[') {
+				c.pos++
+				seg, err = c.bracket(true)
+			} else {
+				seg.kind = segName
+				seg.name = c.name()
+				if seg.name == "" {
+					return nil, c.fail("expected a member name")
+				}
+			}
+		} else if c.eat('[') {
+			seg, err = c.bracket(false)
+		} else {
+			return nil, c.fail("expected '.' or '['")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(c.segs) >= maxPathSegs {
+			return nil, c.fail("too many segments")
+		}
+		c.segs = append(c.segs, seg)
+	}
+	if n := len(c.segs); n > 0 && c.segs[n-1].kind == segFilter {
+		// The value a filter selects would have to be handed over before the
+		// member that decides it has been read.
+		c.pos = start
+		return nil, c.fail("a filter can't be the last segment")
+	}
+	return &Path{expr, c.segs}, nil
+}
+
+// MustCompilePath is like CompilePath, but panics if the expression can't be
+// compiled. It is intended for initializing global variables.
+func MustCompilePath(expr string) *Path {
+	p, err := CompilePath(expr)
+	if err != nil {
+		panic(err)
+	}
+	return p
+}
+
+// String returns the source text of the expression.
+func (p *Path) String() string {
+	return p.expr
+}
+
+// pathCompiler holds the state of CompilePath.
+type pathCompiler struct {
+	expr string
+	pos  int
+	segs []pathSeg
+}
+
+// fail returns an ErrInvalidPath for the current position.
+func (c *pathCompiler) fail(msg string) error {
+	return ErrInvalidPath{c.expr, c.pos, msg}
+}
+
+// peek is true if the next byte is the given one.
+func (c *pathCompiler) peek(b byte) bool {
+	return c.pos < len(c.expr) && c.expr[c.pos] == b
+}
+
+// eat consumes the next byte if it is the given one.
+func (c *pathCompiler) eat(b byte) bool {
+	if c.peek(b) {
+		c.pos++
+		return true
+	}
+	return false
+}
+
+// space skips whitespace within a bracket.
+func (c *pathCompiler) space() {
+	for c.peek(' ') || c.peek('\t') {
+		c.pos++
+	}
+}
+
+// name reads a member name in dot notation.
+func (c *pathCompiler) name() string {
+	start := c.pos
+	for c.pos < len(c.expr) {
+		b := c.expr[c.pos]
+		if b != '_' && b != '-' && b < 0x80 && (b < '0' || b > '9') &&
+			(b < 'A' || b > 'Z') && (b < 'a' || b > 'z') {
+			break
+		}
+		c.pos++
+	}
+	return c.expr[start:c.pos]
+}
+
+// quoted reads a quoted member name, in single or double quotes.
+func (c *pathCompiler) quoted() (string, error) {
+	q := c.expr[c.pos]
+	c.pos++
+	var bld strings.Builder
+	for c.pos < len(c.expr) {
+		b := c.expr[c.pos]
+		c.pos++
+		if b == q {
+			return bld.String(), nil
+		} else if b == '\\' {
+			if c.pos >= len(c.expr) {
+				break
+			}
+			b = c.expr[c.pos]
+			c.pos++
+		}
+		bld.WriteByte(b)
+	}
+	return "", c.fail("unterminated string")
+}
+
+// integer reads a non-negative integer. If there is none, ok is false.
+func (c *pathCompiler) integer() (n int64, ok bool, err error) {
+	if c.peek('-') {
+		return 0, false, c.fail("negative indices are not supported")
+	}
+	start := c.pos
+	for c.pos < len(c.expr) && c.expr[c.pos] >= '0' && c.expr[c.pos] <= '9' {
+		c.pos++
+	}
+	if c.pos == start {
+		return 0, false, nil
+	}
+	n, err = strconv.ParseInt(c.expr[start:c.pos], 10, 64)
+	if err != nil {
+		c.pos = start
+		return 0, false, c.fail("index out of range")
+	}
+	return n, true, nil
+}
+
+// bracket reads a segment in bracket notation, after the opening bracket.
+func (c *pathCompiler) bracket(desc bool) (pathSeg, error) {
+	seg := pathSeg{desc: desc}
+	var err error
+	c.space()
+	switch {
+	case c.eat('*'):
+		seg.kind = segWild
+	case c.peek('\''), c.peek('"'):
+		seg.kind = segName
+		seg.name, err = c.quoted()
+	case c.eat('?'):
+		seg.kind = segFilter
+		seg.filter, err = c.filter()
+	default:
+		err = c.slice(&seg)
+	}
+	if err != nil {
+		return seg, err
+	}
+	c.space()
+	if !c.eat(']') {
+		return seg, c.fail("expected ']'")
+	}
+	return seg, nil
+}
+
+// slice reads an index or a slice.
+func (c *pathCompiler) slice(seg *pathSeg) error {
+	n, ok, err := c.integer()
+	if err != nil {
+		return err
+	}
+	c.space()
+	if !c.peek(':') {
+		if !ok {
+			return c.fail("expected an index, a quoted name, '*', or '?'")
+		}
+		seg.kind = segIndex
+		seg.start = n
+		return nil
+	}
+	seg.kind = segSlice
+	seg.start, seg.end, seg.step = n, -1, 1
+	c.pos++
+	c.space()
+	n, ok, err = c.integer()
+	if err != nil {
+		return err
+	} else if ok {
+		seg.end = n
+	}
+	c.space()
+	if c.eat(':') {
+		c.space()
+		n, ok, err = c.integer()
+		if err != nil {
+			return err
+		} else if ok && n == 0 {
+			return c.fail("slice step must be positive")
+		} else if ok {
+			seg.step = n
+		}
+	}
+	return nil
+}
+
+// filter reads a filter expression, after the question mark.
+func (c *pathCompiler) filter() (pathFilter, error) {
+	var f pathFilter
+	var err error
+	if !c.eat('(') {
+		return f, c.fail("expected '('")
+	}
+	c.space()
+	if !c.eat('@') {
+		return f, c.fail("expected '@'")
+	}
+	if c.eat('.') {
+		f.key = c.name()
+		if f.key == "" {
+			return f, c.fail("expected a member name")
+		}
+	} else if c.eat('[') {
+		c.space()
+		if !c.peek('\'') && !c.peek('"') {
+			return f, c.fail("expected a quoted name")
+		}
+		f.key, err = c.quoted()
+		if err != nil {
+			return f, err
+		}
+		c.space()
+		if !c.eat(']') {
+			return f, c.fail("expected ']'")
+		}
+	} else {
+		return f, c.fail("expected '.' or '['")
+	}
+	c.space()
+	if c.eat(')') {
+		f.exists = true
+		return f, nil
+	}
+	if !c.eat('=') || !c.eat('=') {
+		return f, c.fail("expected '==' or ')'")
+	}
+	c.space()
+	err = c.literal(&f)
+	if err != nil {
+		return f, err
+	}
+	c.space()
+	if !c.eat(')') {
+		return f, c.fail("expected ')'")
+	}
+	return f, nil
+}
+
+// literal reads the value a filter compares against.
+func (c *pathCompiler) literal(f *pathFilter) error {
+	var err error
+	rest := c.expr[c.pos:]
+	switch {
+	case c.peek('\''), c.peek('"'):
+		f.typ = String
+		f.str, err = c.quoted()
+		return err
+	case strings.HasPrefix(rest, "true"):
+		f.typ, f.b = Bool, true
+		c.pos += 4
+	case strings.HasPrefix(rest, "false"):
+		f.typ = Bool
+		c.pos += 5
+	case strings.HasPrefix(rest, "null"):
+		f.typ = Null
+		c.pos += 4
+	default:
+		start := c.pos
+		for c.pos < len(c.expr) && strings.IndexByte("+-.0123456789Ee", c.expr[c.pos]) >= 0 {
+			c.pos++
+		}
+		f.typ = Number
+		f.num, err = strconv.ParseFloat(c.expr[start:c.pos], 64)
+		if err != nil {
+			c.pos = start
+			return c.fail("expected a string, number, boolean, or null")
+		}
+	}
+	return nil
+}
+
+// query holds the state of a call to Query.
+type query struct {
+	segs  []pathSeg
+	final uint64
+	fn    func(*JsonValue) error
+	// match is the value handed to fn. It is a single slot shared by every
+	// match, so that the values being matched can stay on the stack.
+	match JsonValue
+	// str holds the raw text of a String tested by a filter, which is read from
+	// the copy once the filter has been tested. tmp is the buffer it is parsed
+	// with, and offs is the file offset of the original.
+	str  []byte
+	tmp  buffer
+	offs uint64
+	// held holds the raw text of the members of Objects that are waiting on
+	// a filter, which are listed in pend. Each Object being visited owns the
+	// entries past those of the Objects that contain it.
+	held []byte
+	pend []heldMember
+}
+
+// heldMember is a member of an Object that could be selected through a filter
+// that hasn't been decided yet. Its raw text is held in query.held, from start
+// to end, and offs is the file offset of the original.
+type heldMember struct {
+	name       string
+	match      bool
+	start, end int
+	offs       uint64
+}
+
+// Query runs a compiled JSONPath expression over this value, in a single
+// forward pass, and calls fn for each value that matches. Each match is handed
+// to fn as a live JsonValue, which fn may read from as it likes; once fn
+// returns, anything left of the match is discarded. If fn returns an error, the
+// query stops, and the error is returned.
+//
+// Anything that can't contain a match is skipped as with Close(). Since each
+// match is handed over before anything within it is examined, a match nested
+// inside another match (as can happen with recursive descent) is not reported.
+//
+// A filter is decided by the member it tests, as the members of an Object are
+// read, and the members that follow it are matched live. A tested String is
+// held in memory until it has been compared. A member that comes before the
+// tested one, and could be selected through the filter, is held in memory too,
+// as raw text, and matched once the filter has been decided (or, if the tested
+// member is missing, once the Object ends). For example, with
+// "$.*[?(@.version)].name", a "name" that comes before "version" is held until
+// "version" is read. Matches made from a copy held in memory have no Path, and
+// a held member is reported after the members that were read after it.
+func (data *JsonValue) Query(p *Path, fn func(*JsonValue) error) error {
+	q := query{segs: p.segs, final: 1 << uint(len(p.segs)), fn: fn}
+	return q.visit(data, 1, 0)
+}
+
+// visit matches a value against the given set of states. Bit i of the set is
+// on if the value is matched by the first i segments of the path. Bit i of
+// filters is on if segment i is a filter that applies to this value, in which
+// case bit i+1 of the set is decided by the members of an Object.
+func (q *query) visit(data *JsonValue, set uint64, filters uint64) error {
+	if set&q.final != 0 {
+		q.match = *data
+		err := q.fn(&q.match)
+		if err != nil {
+			return err
+		}
+		return q.match.Close()
+	}
+	switch data.Type {
+	case Object:
+		return q.visitObject(data, set, filters)
+	case Array:
+		return q.visitArray(data, set)
+	}
+	return data.Close()
+}
+
+// visitObject matches each member of an Object. Once the member tested by a
+// filter has been read, the filter is decided, and the state it leads to is
+// added to the set for the members that follow. The members held back by the
+// Object are dropped once it has been visited.
+func (q *query) visitObject(data *JsonValue, set uint64, filters uint64) error {
+	pend, held := len(q.pend), len(q.held)
+	err := q.visitMembers(data, set, filters, pend)
+	q.pend, q.held = q.pend[:pend], q.held[:held]
+	return err
+}
+
+// visitMembers does the work of visitObject. Members that could be selected
+// through an undecided filter are held, from pend on, until every filter has
+// been decided.
+func (q *query) visitMembers(data *JsonValue, set uint64, filters uint64, pend int) error {
+	var arr [8]string
+	names := arr[:0]
+	for s := range q.segs {
+		bit := uint64(1) << uint(s)
+		if (set|filters<<1)&bit != 0 && q.segs[s].kind == segName {
+			names = append(names, q.segs[s].name)
+		}
+		if filters&bit != 0 {
+			names = append(names, q.segs[s].filter.key)
+		}
+	}
+	simpleSort(names)
+	var val JsonValue
+	for {
+		key, err := data.NextKey()
+		if err == EndOfValue {
+			// Any filter that is still undecided tests a missing member, and
+			// so fails.
+			return q.replay(set, pend)
+		} else if err != nil {
+			return err
+		}
+		name, match := "", false
+		if len(names) > 0 {
+			name, match, err = compareRead(&key, names)
+		} else {
+			err = key.Close()
+		}
+		if err != nil {
+			return err
+		}
+		tests := q.tests(filters, name, match)
+		next, sub := q.step(set, name, match, -1)
+		var maybe, msub uint64
+		if filters&^tests != 0 {
+			maybe, msub = q.step((filters&^tests)<<1, name, match, -1)
+			maybe, msub = maybe&^next, msub&^sub
+		}
+		if tests == 0 && next == 0 && sub == 0 && maybe == 0 && msub == 0 {
+			continue
+		}
+		val, err = data.NextValue()
+		if err != nil {
+			return err
+		}
+		if tests != 0 {
+			// The filters are decided before anything else, since they may
+			// select this member too. A String is consumed by each test, so
+			// it is tested and matched from a fresh copy each time.
+			if val.Type == String {
+				err = q.copyString(&val)
+				if err != nil {
+					return err
+				}
+			}
+			for s := range q.segs {
+				if tests&(1<<uint(s)) == 0 {
+					continue
+				}
+				if val.Type == String {
+					val = q.parseString()
+				}
+				ok, err := q.segs[s].filter.test(&val)
+				if err != nil {
+					return err
+				} else if ok {
+					set |= 1 << uint(s+1)
+				}
+			}
+			filters &^= tests
+			next, sub = q.step(set, name, match, -1)
+			if val.Type == String {
+				val = q.parseString()
+			}
+		}
+		// A scalar can't contain a match, so it only matters if an undecided
+		// filter could make it one.
+		if maybe|msub != 0 && (val.Type == Object || val.Type == Array) ||
+			maybe&q.final != 0 {
+			err = q.hold(&val, name, match)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		err = q.visit(&val, next, sub)
+		if err == nil && tests != 0 && filters == 0 {
+			// This member was visited first, since a String tested here is
+			// held in the same place as one tested within a held member.
+			err = q.replay(set, pend)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// tests finds the filters that are decided by the member with the given key.
+func (q *query) tests(filters uint64, name string, match bool) uint64 {
+	var tests uint64
+	for s := range q.segs {
+		if filters&(1<<uint(s)) != 0 && match && q.segs[s].filter.key == name {
+			tests |= 1 << uint(s)
+		}
+	}
+	return tests
+}
+
+// hold copies the raw text of a member into memory, to be matched once the
+// filters it waits on have been decided.
+func (q *query) hold(data *JsonValue, name string, match bool) error {
+	h := heldMember{name, match, len(q.held), 0, data.offs}
+	held, err := appendRaw(q.held, data)
+	q.held = held
+	if err != nil {
+		return err
+	}
+	h.end = len(q.held)
+	q.pend = append(q.pend, h)
+	return nil
+}
+
+// replay matches the members held from pend on against the given set of
+// states, and then drops them. Each is parsed with a buffer of its own, since
+// it may hold members of its own while it is visited.
+func (q *query) replay(set uint64, pend int) error {
+	if len(q.pend) == pend {
+		return nil
+	}
+	tmp := new(buffer)
+	for i := pend; i < len(q.pend); i++ {
+		h := q.pend[i]
+		*tmp = bytesBuffer(q.held[h.start:h.end])
+		tmp.foffs += h.offs
+		next(tmp)
+		val, err := readValue(tmp)
+		if err != nil {
+			return err
+		}
+		states, sub := q.step(set, h.name, h.match, -1)
+		err = q.visit(&val, states, sub)
+		if err != nil {
+			return err
+		}
+	}
+	start := q.pend[pend].start
+	q.pend, q.held = q.pend[:pend], q.held[:start]
+	return nil
+}
+
+// copyString copies the raw text of a String tested by a filter into memory.
+func (q *query) copyString(data *JsonValue) error {
+	q.offs = data.offs
+	str, err := appendRaw(q.str[:0], data)
+	q.str = str
+	return err
+}
+
+// parseString begins to parse the copy of a String made by copyString. The
+// file offsets of the copy are those of the original.
+func (q *query) parseString() JsonValue {
+	q.tmp = bytesBuffer(q.str)
+	q.tmp.foffs += q.offs
+	next(&q.tmp)
+	val, _ := readValue(&q.tmp)
+	return val
+}
+
+// visitArray matches each element of an Array.
+func (q *query) visitArray(data *JsonValue, set uint64) error {
+	var val JsonValue
+	for i := int64(0); ; i++ {
+		if !q.more(set, i) {
+			return data.Close()
+		}
+		var err error
+		val, err = data.NextValue()
+		if err == EndOfValue {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		next, filters := q.step(set, "", false, i)
+		if next == 0 && filters == 0 {
+			err = val.Close()
+		} else {
+			err = q.visit(&val, next, filters)
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// step finds the states that apply to a child of a value in the given states.
+// The child has the given key if match is true, or the given index if idx isn't
+// negative. States with filters are returned separately, since whether they
+// advance depends on the content of the child.
+func (q *query) step(set uint64, name string, match bool, idx int64) (uint64, uint64) {
+	var next, filters uint64
+	for s := range q.segs {
+		if set&(1<<uint(s)) == 0 {
+			continue
+		}
+		seg := &q.segs[s]
+		if seg.desc {
+			next |= 1 << uint(s)
+		}
+		var ok bool
+		switch seg.kind {
+		case segName:
+			ok = match && name == seg.name
+		case segWild:
+			ok = true
+		case segIndex:
+			ok = idx == seg.start
+		case segSlice:
+			ok = idx >= seg.start && (seg.end < 0 || idx < seg.end) &&
+				(idx-seg.start)%seg.step == 0
+		case segFilter:
+			filters |= 1 << uint(s)
+		}
+		if ok {
+			next |= 1 << uint(s+1)
+		}
+	}
+	return next, filters
+}
+
+// more is true if any of the given states can match an element of an Array at
+// the given index or later.
+func (q *query) more(set uint64, idx int64) bool {
+	for s := range q.segs {
+		if set&(1<<uint(s)) == 0 {
+			continue
+		}
+		seg := &q.segs[s]
+		switch {
+		case seg.desc, seg.kind == segWild, seg.kind == segFilter:
+			return true
+		case seg.kind == segIndex && idx <= seg.start:
+			return true
+		case seg.kind == segSlice && (seg.end < 0 || idx < seg.end):
+			return true
+		}
+	}
+	return false
+}
+
+// test reports whether the member a filter tests passes it. Filters only
+// compare scalars, so an Object or Array passes only if the filter checks for
+// existence, and isn't read.
+func (f *pathFilter) test(val *JsonValue) (bool, error) {
+	if f.exists {
+		return true, nil
+	} else if val.Type != f.typ {
+		return false, nil
+	}
+	switch f.typ {
+	case String:
+		_, ok, err := val.Compare(f.str)
+		return ok, err
+	case Number:
+		n, err := val.ValueNum()
+		return err == nil && n == f.num, nil
+	case Bool:
+		return val.boolval == f.b, nil
+	}
+	return true, nil
+}
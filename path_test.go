@@ -0,0 +1,206 @@
+package jsonmuncher
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// queryAll runs a query and collects the raw text of every match.
+func queryAll(json string, expr string) ([]string, error) {
+	p, err := CompilePath(expr)
+	if err != nil {
+		return nil, err
+	}
+	data, err := Parse(strings.NewReader(json), 8)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	err = data.Query(p, func(v *JsonValue) error {
+		raw, err := appendRaw(nil, v)
+		out = append(out, string(raw))
+		return err
+	})
+	return out, err
+}
+
+func TestQuery(t *testing.T) {
+	json := `{"apiVersion":"v1","entries":{"a":[{"name":"a","version":"1.0"},{"name":"a2"}],` +
+		`"b":[{"name":"b","urls":["x","y"],"version":"2.0"},{"name":"b3","version":3}]},` +
+		`"list":[0,1,2,3,4,5,6,7,8,9],"deep":{"name":{"name":"inner"}}}`
+	tests := []struct {
+		expr string
+		out  string
+	}{
+		{"$", json},
+		{"$.apiVersion", `"v1"`},
+		{"$['apiVersion']", `"v1"`},
+		{"$.list[?(@.name)].x", ``},
+		{"$.entries.b[0].urls[1]", `"y"`},
+		{"$.entries.b[0].urls.*", `"x" "y"`},
+		{"$.list[2:5]", `2 3 4`},
+		{"$.list[7:]", `7 8 9`},
+		{"$.list[:2]", `0 1`},
+		{"$.list[1::4]", `1 5 9`},
+		{"$.list[12]", ``},
+		{"$..name", `"a" "a2" "b" "b3" {"name":"inner"}`},
+		{"$..urls[0]", `"x"`},
+		{"$.deep..name", `{"name":"inner"}`},
+		{"$.deep.name..*", `"inner"`},
+		{"$..[1]", `{"name":"a2"} "y" {"name":"b3","version":3} 1`},
+		{"$.missing.name", ``},
+		{"$.apiVersion.name", ``},
+	}
+	for _, test := range tests {
+		out, err := queryAll(json, test.expr)
+		assert(t, err != nil || strings.Join(out, " ") != test.out,
+			test.expr, strings.Join(out, " "), err)
+	}
+}
+
+func TestQueryFilter(t *testing.T) {
+	json := `{"entries":{"a":[{"name":"a","version":"1.0"},{"id":"a2"}],` +
+		`"b":[{"version":"2.0","name":"b","urls":["x","y"]},{"name":"b3","version":3}]}}`
+	tests := []struct {
+		expr string
+		out  string
+	}{
+		{"$.entries.*[?(@.version)].name", `"a" "b" "b3"`},
+		{"$.entries.*[?(@.version == '2.0')].name", `"b"`},
+		{"$.entries.*[?(@['version'] == 3)].name", `"b3"`},
+		{"$.entries.b[?(@.version == 3.0)].*", `3 "b3"`},
+		{"$.entries.*[?(@.version == '3')].name", ``},
+		{"$.entries.*[?(@.id)].name", ``},
+		{"$[?(@.a)].b[0].name", `"b"`},
+		{"$..[?(@.version)].urls[1]", `"y"`},
+		{"$..[?(@.version)].version", `"1.0" "2.0" 3`},
+	}
+	for _, test := range tests {
+		out, err := queryAll(json, test.expr)
+		assert(t, err != nil || strings.Join(out, " ") != test.out,
+			test.expr, strings.Join(out, " "), err)
+	}
+	out, err := queryAll(`{"o":{"a":{"p":{"x":1,"w":true},"q":{"x":2}},"v":1}}`, "$[?(@.v)].*[?(@.w)].x")
+	assert(t, err != nil || strings.Join(out, " ") != "1",
+		"1", out, err)
+	p := NewParser(strings.NewReader(json), 8)
+	p.TrackPath = make([]byte, 0, 32)
+	data, _ := p.Next()
+	var paths []string
+	err = data.Query(MustCompilePath("$.entries.*[?(@.version)].name"), func(v *JsonValue) error {
+		paths = append(paths, v.Path())
+		return nil
+	})
+	// Names that come before the version are matched from a copy.
+	assert(t, err != nil || strings.Join(paths, " ") != " /entries/b/0/name ",
+		"2", paths, err)
+}
+
+func TestQueryFilterFixture(t *testing.T) {
+	// The members are in the order of fixture_huge.json, with the tested
+	// member after the selected ones.
+	json := `{"entries":{"dummy-chart-1":[{"name":"dummy-chart-1","home":"https://example.com",` +
+		`"sources":["https://example.com"],"version":"1.2.1","description":"Example description",` +
+		`"maintainers":[{"name":"Bar","email":"bar@example.com"}]},` +
+		`{"name":"dummy-chart-1","sources":[],"version":"1.2.2"}],` +
+		`"dummy-chart-2":[{"name":"dummy-chart-2","sources":[]}]}}`
+	tests := []struct {
+		expr string
+		out  string
+	}{
+		{"$.entries.*[?(@.version)].name", `"dummy-chart-1" "dummy-chart-1"`},
+		{"$.entries.*[?(@.version == '1.2.2')].name", `"dummy-chart-1"`},
+		{"$.entries.*[?(@.version == '1.2.1')].sources[0]", `"https://example.com"`},
+		{"$.entries.*[?(@.version)].maintainers[0].name", `"Bar"`},
+	}
+	for _, test := range tests {
+		out, err := queryAll(json, test.expr)
+		assert(t, err != nil || strings.Join(out, " ") != test.out,
+			test.expr, strings.Join(out, " "), err)
+	}
+}
+
+func TestQueryAllocs(t *testing.T) {
+	json := `{"entries":{"a":[{"version":"1.0","name":"a"}],"b":[{"version":2,"name":"b"}]}}`
+	r := strings.NewReader(json)
+	p := NewParser(r, 8)
+	path := MustCompilePath("$.entries.*[?(@.version)].name")
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(json)
+		p.Reset(r)
+		data, _ := p.Next()
+		data.Query(path, func(v *JsonValue) error {
+			return nil
+		})
+	})
+	// At most two, for the state of the query and the copy of a tested String.
+	assert(t, allocs > 2,
+		"1", allocs)
+}
+
+func TestQueryStop(t *testing.T) {
+	p := MustCompilePath("$.*")
+	data, _ := Parse(strings.NewReader("[1,2,3]"), 4)
+	stop := errors.New("stop")
+	var n float64
+	err := data.Query(p, func(v *JsonValue) error {
+		n, _ = v.ValueNum()
+		if n == 2 {
+			return stop
+		}
+		return nil
+	})
+	assert(t, err != stop || n != 2,
+		"1", err, n)
+	n2, _ := data.NextValue()
+	n, _ = n2.ValueNum()
+	assert(t, n != 3,
+		"2", n)
+}
+
+func TestCompilePath(t *testing.T) {
+	tests := []struct {
+		expr string
+		err  string
+	}{
+		{"a", "Invalid JSONPath \"a\" at offset 0: expected '$'"},
+		{"$a", "Invalid JSONPath \"$a\" at offset 1: expected '.' or '['"},
+		{"$.", "Invalid JSONPath \"$.\" at offset 2: expected a member name"},
+		{"$[-1]", "Invalid JSONPath \"$[-1]\" at offset 2: negative indices are not supported"},
+		{"$[1", "Invalid JSONPath \"$[1\" at offset 3: expected ']'"},
+		{"$['a]", "Invalid JSONPath \"$['a]\" at offset 5: unterminated string"},
+		{"$[::0]", "Invalid JSONPath \"$[::0]\" at offset 5: slice step must be positive"},
+		{"$[?(@.a = 1)]", "Invalid JSONPath \"$[?(@.a = 1)]\" at offset 9: expected '==' or ')'"},
+		{"$[?(@.a == x)]", "Invalid JSONPath \"$[?(@.a == x)]\" at offset 11: expected a string, number, boolean, or null"},
+		{"$[]", "Invalid JSONPath \"$[]\" at offset 2: expected an index, a quoted name, '*', or '?'"},
+		{"$.a[?(@.b)]", "Invalid JSONPath \"$.a[?(@.b)]\" at offset 3: a filter can't be the last segment"},
+	}
+	for _, test := range tests {
+		_, err := CompilePath(test.expr)
+		assert(t, err == nil || err.Error() != test.err,
+			test.expr, err)
+	}
+	p, err := CompilePath("$..a[ 'b\\'c' ][1:2][?( @.d == null )].e")
+	assert(t, err != nil || p.String() != "$..a[ 'b\\'c' ][1:2][?( @.d == null )].e" || len(p.segs) != 5,
+		"1", err)
+	assert(t, p.segs[1].name != "b'c" || p.segs[3].filter.typ != Null,
+		"2", p.segs[1].name, p.segs[3].filter.typ)
+}
+
+func TestQueryRaw(t *testing.T) {
+	json := `{"s":"a\\\"b\\\\c\"d","o":{"x":["]","\\","}"]},"n":-1.5e3}`
+	p := MustCompilePath("$.*")
+	for size := 1; size <= len(json); size++ {
+		data, _ := Parse(strings.NewReader(json), size)
+		var out []string
+		err := data.Query(p, func(v *JsonValue) error {
+			raw, err := appendRaw(nil, v)
+			out = append(out, string(raw))
+			return err
+		})
+		res := strings.Join(out, " ")
+		assert(t, err != nil || res != `"a\\\"b\\\\c\"d" {"x":["]","\\","}"]} -1.5e3`,
+			size, res, err)
+	}
+}
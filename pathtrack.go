@@ -0,0 +1,263 @@
+package jsonmuncher
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// The path of the value being read is kept in the options as a JSON pointer, one
+// segment for each level of nesting below the top-level value. Each segment is
+// a '/' followed by an array index, or by the raw text of a key as it appears
+// in the stream, with any '~' or '/' already escaped as "~0" or "~1" so that
+// segments can be told apart. The JSON escapes within keys are decoded by
+// Path(). The slice never grows beyond the capacity it was given; a segment
+// that doesn't fit is left out, along with everything below it.
+
+// ParseWithPath is like Parse, but keeps track of the path to the value being
+// read, which is reported by Path() and included in errors. The path is stored
+// in the given slice, up to its capacity, so no memory is allocated to track it.
+// If the path grows too long to fit, the deepest part of it is cut off. To
+// combine path tracking with a context or limits, use a Parser.
+func ParseWithPath(r io.Reader, size int, path []byte) (JsonValue, error) {
+	buf := newOptBuffer(r, size)
+	startPath(buf.opts, path)
+	_ = feedq(buf) && feed(buf)
+	next(buf)
+	return readValue(buf)
+}
+
+// startPath enables path tracking, using the given slice for storage.
+func startPath(o *options, path []byte) {
+	o.path = path[:0]
+	o.segs = 0
+	o.pathcut = false
+	o.capture = false
+}
+
+// truncPath removes segments from the path until there are at most n. If a key
+// is still being captured, it is dropped first.
+func truncPath(o *options, n uint32) {
+	if o.capture {
+		o.path = o.path[:o.segoffs]
+		o.capture = false
+	}
+	if n > o.segs {
+		return
+	}
+	for o.segs > n {
+		o.path = o.path[:strings.LastIndexByte(string(o.path), '/')]
+		o.segs--
+	}
+	o.pathcut = false
+}
+
+// pushIndex sets the path segment below an Array at the given depth to the
+// given index.
+func pushIndex(buf *buffer, depth uint32, idx uint64) {
+	o := buf.opts
+	truncPath(o, depth-1)
+	if o.segs < depth-1 {
+		return
+	}
+	var arr [21]byte
+	seg := strconv.AppendUint(append(arr[:0], '/'), idx, 10)
+	if len(o.path)+len(seg) > cap(o.path) {
+		o.pathcut = true
+		return
+	}
+	o.path = append(o.path, seg...)
+	o.segs++
+}
+
+// pushKey sets the path segment below an Object at the given depth to the key
+// that has just been started. The text of the key is captured as it is read.
+func pushKey(buf *buffer, depth uint32) {
+	o := buf.opts
+	truncPath(o, depth-1)
+	if o.segs < depth-1 {
+		return
+	}
+	o.segoffs = uint32(len(o.path))
+	o.capture = true
+	o.capoffs = buf.offs - 1
+	if len(o.path) == cap(o.path) {
+		o.pathcut = true
+		return
+	}
+	o.path = append(o.path, '/')
+}
+
+// captureKey adds the text of the key being captured, up to the given offset
+// in the read buffer, to the path.
+func captureKey(buf *buffer, end uint32) {
+	o := buf.opts
+	text := buf.data[o.capoffs:end]
+	o.capoffs = end
+	if o.pathcut {
+		return
+	}
+	for _, c := range text {
+		n := len(o.path)
+		switch c {
+		case '~', '/':
+			if n+2 > cap(o.path) {
+				o.pathcut = true
+				return
+			}
+			if c == '~' {
+				o.path = append(o.path, '~', '0')
+			} else {
+				o.path = append(o.path, '~', '1')
+			}
+		default:
+			if n == cap(o.path) {
+				o.pathcut = true
+				return
+			}
+			o.path = append(o.path, c)
+		}
+	}
+}
+
+// endKey finishes capturing a key, whose closing quote is at the given offset
+// in the read buffer.
+func endKey(buf *buffer, end uint32) {
+	captureKey(buf, end)
+	o := buf.opts
+	o.capture = false
+	if o.pathcut {
+		o.path = o.path[:o.segoffs]
+	} else {
+		o.segs++
+	}
+}
+
+// pathString builds the path to a value at the given depth, from the segments
+// that have been recorded.
+func pathString(o *options, depth uint32) string {
+	need := depth - 1
+	if depth == 0 {
+		need = 0
+	}
+	var bld strings.Builder
+	p := o.path
+	for i := uint32(0); i < need && i < o.segs; i++ {
+		end := 1 + strings.IndexByte(string(p[1:]), '/')
+		if end == 0 {
+			end = len(p)
+		}
+		writeSegment(&bld, p[:end])
+		p = p[end:]
+	}
+	if need > o.segs && o.pathcut {
+		bld.WriteString("/…")
+	}
+	return bld.String()
+}
+
+// writeSegment decodes the JSON escapes in a path segment, and escapes any '~'
+// or '/' they produce.
+func writeSegment(bld *strings.Builder, seg []byte) {
+	for i := 0; i < len(seg); i++ {
+		c := seg[i]
+		if c != '\\' || i+1 >= len(seg) {
+			bld.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = seg[i]; c {
+		case '~':
+			// An escaped '/', which was escaped again when it was captured.
+			bld.WriteString("~1")
+			i++
+		case 'u':
+			r, n := segmentRune(seg[i+1:])
+			i += n
+			switch r {
+			case '~':
+				bld.WriteString("~0")
+			case '/':
+				bld.WriteString("~1")
+			default:
+				bld.WriteRune(r)
+			}
+		default:
+			if int(c) < len(escapemap) && escapemap[c] != 0 {
+				c = escapemap[c]
+			}
+			bld.WriteByte(c)
+		}
+	}
+}
+
+// segmentRune decodes the hex digits of a unicode escape (and of the second
+// escape of a surrogate pair) in a path segment, and returns the rune along with
+// the number of bytes used.
+func segmentRune(s []byte) (rune, int) {
+	r1, ok := segmentHex(s)
+	if !ok {
+		return utf8.RuneError, 0
+	} else if r1 < 0xD800 || r1 > 0xDFFF {
+		return r1, 4
+	}
+	if len(s) >= 6 && s[4] == '\\' && s[5] == 'u' {
+		r2, ok := segmentHex(s[6:])
+		if ok && r2 >= 0xDC00 && r2 <= 0xDFFF {
+			return 0x10000 + (r1-0xD800)<<10 + (r2 - 0xDC00), 10
+		}
+	}
+	return utf8.RuneError, 4
+}
+
+// segmentHex decodes four hex digits.
+func segmentHex(s []byte) (rune, bool) {
+	if len(s) < 4 {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(s[:4]), 16, 32)
+	return rune(n), err == nil
+}
+
+// errPath returns the path to include in an error, if path tracking is on. This
+// is the path to the deepest value that has been started.
+func errPath(buf *buffer) string {
+	if buf.opts == nil || buf.opts.path == nil {
+		return ""
+	}
+	return pathString(buf.opts, buf.depth+1)
+}
+
+// newErrWrongType returns an ErrTypeMismatch for a method called on a value of
+// the wrong type, including the path to the value if paths are tracked.
+func newErrWrongType(data *JsonValue, e ...JsonType) ErrTypeMismatch {
+	err := newErrTypeMismatch(data.Type, e...)
+	err.Path = data.Path()
+	return err
+}
+
+// withPath wraps an error returned for a value in an ErrWithPath, if paths are
+// tracked and the value isn't the top-level value.
+func withPath(data *JsonValue, err error) error {
+	path := data.Path()
+	if path == "" {
+		return err
+	}
+	return ErrWithPath{err, path}
+}
+
+// Path returns the location of this value within the top-level value, as a JSON
+// pointer (described by RFC 6901), such as "/topics/3/slug". Paths are only
+// tracked for values read with ParseWithPath(), or from a Parser with TrackPath
+// set; otherwise, Path returns an empty string. The path is built from the keys
+// and indices the parser is currently working through, so it is only accurate
+// while this value is being read. For a key, it is the path to the associated
+// value, once the key has been read. If the path was too long to fit in the
+// slice provided for it, it ends with "/…".
+func (data *JsonValue) Path() string {
+	if data.buffer == nil || data.buffer.opts == nil || data.buffer.opts.path == nil {
+		return ""
+	}
+	return pathString(data.buffer.opts, data.depth)
+}
@@ -0,0 +1,125 @@
+package jsonmuncher
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestPath(t *testing.T) {
+	json := "{\"a\": [1, {\"b~/c\": [true, \"x\"]}, 3], \"k\\u002Fe\\\\y\\\"\": null, \"e\": {\"n\": 12}}"
+	exp := "/a/0|/a/1/b~0~1c/0|/a/1/b~0~1c/1|/a/2|/k~1e\\y\"|/e/n"
+	for size := 1; size <= len(json); size++ {
+		v1, _ := ParseWithPath(strings.NewReader(json), size, make([]byte, 0, 64))
+		tk := NewTokenizer(v1)
+		var out []string
+		var buf [2]byte
+		for {
+			tok, e := tk.Token()
+			if e == io.EOF {
+				break
+			}
+			assert(t, e != nil,
+				"1", size, e)
+			if tok.Kind == TokenKey && tok.Depth == 1 {
+				// Some keys are read, and some are skipped.
+				tok.Value.Read(buf[:])
+			} else if tok.Kind == TokenValue {
+				out = append(out, tok.Value.Path())
+			}
+		}
+		res := strings.Join(out, "|")
+		assert(t, res != exp,
+			"2", size, res)
+		assert(t, v1.Path() != "",
+			"3", size, v1.Path())
+	}
+	v1, _ := Parse(strings.NewReader("{\"a\": 1}"), 4)
+	v2, _, _ := v1.Seek("/a")
+	assert(t, v2.Path() != "",
+		"4", v2.Path())
+}
+
+func TestPathErrors(t *testing.T) {
+	json := "{\"a\": [1, {\"b\": [tru]}]}"
+	v1, _ := ParseWithPath(strings.NewReader(json), 4, make([]byte, 0, 64))
+	e := v1.Close()
+	assert(t, e != nil,
+		"1", e)
+	v1, _ = ParseWithPath(strings.NewReader(json), 4, make([]byte, 0, 64))
+	v2, _, _ := v1.Seek("/a/1/b")
+	_, e = v2.NextValue()
+	assert(t, e == nil || e.Error() != "Unexpected ']' at file offset 20 (path /a/1/b/0), expected 'e'",
+		"2", e)
+	v1, _ = ParseWithPath(strings.NewReader("{\"e\": {\"n\": -x}}"), 4, make([]byte, 0, 64))
+	v2, _, _ = v1.Seek("/e/n")
+	_, e = v2.ValueNum()
+	assert(t, e == nil || e.Error() != "Unexpected 'x' at file offset 13 (path /e/n), expected one of '0'-'9'",
+		"3", e)
+	_, e = v2.ValueNum()
+	assert(t, !errors.Is(e, ErrIncomplete) || e.Error() != "Status incomplete denotes failed read (path /e/n)",
+		"3a", e)
+	r := strings.NewReader("{\"a\": [{\"b\": 1}]}")
+	p := NewParser(r, 4)
+	p.Limits.MaxDepth = 2
+	p.TrackPath = make([]byte, 0, 64)
+	v1, _ = p.Next()
+	_, _, e = v1.Seek("/a/0/b")
+	assert(t, e != ErrDepthLimit{7, 2, "/a/0"},
+		"4", e)
+	assert(t, e.Error() != "Nesting depth exceeds limit of 2 at file offset 7 (path /a/0)",
+		"5", e)
+	v1, _ = ParseWithPath(strings.NewReader("{\"a\": [1, {\"b\": 1e400}]}"), 4, make([]byte, 0, 64))
+	v2, _, _ = v1.Seek("/a/1/b")
+	_, e = v2.ValueNum()
+	assert(t, e != ErrNumRange{"float64", false, "/a/1/b"},
+		"6", e)
+	assert(t, e.Error() != "Number is out of range for float64 (path /a/1/b)",
+		"7", e)
+	_, e = v2.ValueBool()
+	assert(t, e == nil || e.Error() != "Method cannot be called on type Number, only on Bool (path /a/1/b)",
+		"8", e)
+}
+
+func TestPathTruncated(t *testing.T) {
+	json := "{\"abc\": {\"defgh\": [1]}, \"ij\": [2]}"
+	for size := 1; size <= len(json); size++ {
+		v1, _ := ParseWithPath(strings.NewReader(json), size, make([]byte, 0, 6))
+		v2, _, _ := v1.Seek("/abc/defgh/0")
+		assert(t, v2.Path() != "/abc/…",
+			"1", size, v2.Path())
+		v2.Close()
+		v2, _, _ = v1.Seek("/ij/0")
+		assert(t, v2.Path() != "/ij/0",
+			"2", size, v2.Path())
+	}
+}
+
+func TestPathParser(t *testing.T) {
+	json := "{\"a\": [1]} [[2]]"
+	r := strings.NewReader(json)
+	p := NewParser(r, 4)
+	p.TrackPath = make([]byte, 0, 16)
+	v1, _ := p.Next()
+	v2, _, _ := v1.Seek("/a/0")
+	assert(t, v2.Path() != "/a/0",
+		"1", v2.Path())
+	v2.Close()
+	v1.Close()
+	v1, _ = p.Next()
+	v2, _, _ = v1.Seek("/0/0")
+	assert(t, v2.Path() != "/0/0",
+		"2", v2.Path())
+	v1.Close()
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(json)
+		p.Reset(r)
+		v1, _ := p.Next()
+		v2, _, _ := v1.Seek("/a/0")
+		v2.ValueNum()
+		v1.Close()
+	})
+	assert(t, allocs != 0,
+		"3", allocs)
+}
@@ -0,0 +1,174 @@
+package jsonmuncher
+
+import (
+	"io"
+	"strings"
+)
+
+// checkPointer verifies that a string is a valid JSON pointer, as described by
+// RFC 6901: it must be empty, or begin with a '/', and every '~' must be
+// followed by a '0' or a '1'.
+func checkPointer(pointer string) error {
+	if pointer != "" && pointer[0] != '/' {
+		return ErrInvalidPointer
+	}
+	for i := 0; i < len(pointer); i++ {
+		if pointer[i] == '~' {
+			if i+1 >= len(pointer) || pointer[i+1] != '0' && pointer[i+1] != '1' {
+				return ErrInvalidPointer
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+// matchToken reads a key, and compares it against an escaped reference token
+// from a JSON pointer. The token is unescaped as it is compared, so no copy of
+// it is ever made.
+func matchToken(key *JsonValue, tok string) (bool, error) {
+	var buf [16]byte
+	j := 0
+	for {
+		l, err := key.Read(buf[:])
+		if err != nil && err != io.EOF {
+			return false, err
+		}
+		for i := 0; i < l; i++ {
+			if j >= len(tok) {
+				return false, key.Close()
+			}
+			c := tok[j]
+			j++
+			if c == '~' {
+				c = "~/"[tok[j]-'0']
+				j++
+			}
+			if c != buf[i] {
+				return false, key.Close()
+			}
+		}
+		if err == io.EOF {
+			return j == len(tok), nil
+		}
+	}
+}
+
+// seekKey reads an Object until it finds the key matching the given token, and
+// returns the associated value.
+func seekKey(data *JsonValue, tok string) (JsonValue, bool, error) {
+	for {
+		key, err := data.NextKey()
+		if err == EndOfValue {
+			return JsonValue{}, false, nil
+		} else if err != nil {
+			return JsonValue{}, false, err
+		}
+		match, err := matchToken(&key, tok)
+		if err != nil {
+			return JsonValue{}, false, err
+		} else if match {
+			val, err := data.NextValue()
+			if err != nil {
+				return JsonValue{}, false, err
+			}
+			return val, true, nil
+		}
+	}
+}
+
+// indexToken parses a reference token from a JSON pointer as an array index.
+// Tokens with leading zeros aren't valid indices, and neither is "-", which
+// refers to the element after the last.
+func indexToken(tok string) (uint64, bool) {
+	var idx uint64
+	valid := tok != "" && len(tok) <= 19 && (tok[0] != '0' || len(tok) == 1)
+	for i := 0; i < len(tok) && valid; i++ {
+		valid = tok[i] >= '0' && tok[i] <= '9'
+		idx = 10*idx + uint64(tok[i]-'0')
+	}
+	return idx, valid
+}
+
+// seekIndex reads an Array until it reaches the element at the index given by
+// a token, and returns that element. A token that isn't a valid array index
+// matches nothing, so the Array is discarded.
+func seekIndex(data *JsonValue, tok string) (JsonValue, bool, error) {
+	idx, valid := indexToken(tok)
+	if !valid {
+		return JsonValue{}, false, data.Close()
+	}
+	for {
+		val, err := data.NextValue()
+		if err == EndOfValue {
+			return JsonValue{}, false, nil
+		} else if err != nil {
+			return JsonValue{}, false, err
+		} else if idx == 0 {
+			return val, true, nil
+		}
+		err = val.Close()
+		if err != nil {
+			return JsonValue{}, false, err
+		}
+		idx--
+	}
+}
+
+// Seek is a helper function, designed to find a value nested within an Object
+// or Array by its JSON pointer (as described by RFC 6901), such as
+// "/topics/topics/3/slug". Object keys are matched against the reference tokens
+// of the pointer, and array elements are counted off by index; everything else
+// is discarded along the way. If the value isn't found, false is returned. An
+// empty pointer refers to this value itself, and a copy of it is returned. The
+// copy and the original share the same position in the stream, but not their
+// Status, so only one of them may be read from; the other must not be used
+// again.
+//
+// The Objects and Arrays between this value and the returned one are left open.
+// Once the returned value has been read or closed, they are discarded the next
+// time this value is used, so reading can carry on from there. Seek doesn't
+// allocate, except the first time it leaves values open in a parse with no
+// options set (see Parser), when a small amount of state is set aside for them.
+func (data *JsonValue) Seek(pointer string) (JsonValue, bool, error) {
+	err := checkPointer(pointer)
+	if err != nil {
+		return JsonValue{}, false, err
+	} else if pointer == "" {
+		return *data, true, nil
+	} else if data.Type != Object && data.Type != Array {
+		return JsonValue{}, false, newErrWrongType(data, Array, Object)
+	}
+	cur := data
+	var val JsonValue
+	for pointer != "" {
+		tok := pointer[1:]
+		pointer = ""
+		if i := strings.IndexByte(tok, '/'); i >= 0 {
+			tok, pointer = tok[:i], tok[i:]
+		}
+		var found bool
+		switch cur.Type {
+		case Object:
+			val, found, err = seekKey(cur, tok)
+		case Array:
+			val, found, err = seekIndex(cur, tok)
+		default:
+			// There is nothing to look in, so the value is discarded.
+			err = cur.Close()
+		}
+		if err != nil {
+			return JsonValue{}, false, err
+		} else if !found {
+			if data.buffer.depth > data.depth {
+				orphan(data.buffer, data.depth+1, data.buffer.depth)
+			}
+			return JsonValue{}, false, nil
+		}
+		cur = &val
+	}
+	if val.depth > data.depth+1 {
+		orphan(data.buffer, data.depth+1, val.depth-1)
+	}
+	return val, true, nil
+}
@@ -0,0 +1,97 @@
+package jsonmuncher
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSeek(t *testing.T) {
+	json := "{\"a\":{\"b\":[1,{\"c/d\":2,\"e~f\":3},4],\"g\":5},\"h\":[6]}"
+	v1, _ := Parse(strings.NewReader(json), 4)
+	v2, m, e := v1.Seek("/a/b/1/e~0f")
+	assert(t, m != true || e != nil,
+		"1", m, e)
+	n, e := v2.ValueNum()
+	assert(t, n != 3 || e != nil,
+		"2", n, e)
+	v2, m, e = v1.Seek("/h/0")
+	assert(t, m != true || e != nil,
+		"3", m, e)
+	n, e = v2.ValueNum()
+	assert(t, n != 6 || e != nil,
+		"4", n, e)
+	e = v1.Close()
+	assert(t, e != nil || v1.Status != Complete,
+		"5", e, v1.Status)
+	v1, _ = Parse(strings.NewReader(json), 4)
+	v2, m, e = v1.Seek("/a/b/1/c~1d")
+	n, e = v2.ValueNum()
+	assert(t, n != 2 || e != nil,
+		"6", n, e)
+	k, e := v1.NextKey()
+	assert(t, e != nil,
+		"7", e)
+	_, m, e = k.Compare("h")
+	assert(t, m != true || e != nil,
+		"8", m, e)
+	_, m, e = v1.Seek("/a")
+	assert(t, m != false || e != nil || v1.Status != Complete,
+		"9", m, e, v1.Status)
+	v1, _ = Parse(strings.NewReader(json), 4)
+	v2, m, e = v1.Seek("/a/b")
+	v3, m, e := v2.Seek("/1/e~0f")
+	assert(t, m != true || e != nil,
+		"10", m, e)
+	v3.Close()
+	v3, e = v2.NextValue()
+	n, e = v3.ValueNum()
+	assert(t, n != 4 || e != nil,
+		"11", n, e)
+	v2.Close()
+	s, _, m, e := v1.FindKey("g", "h")
+	assert(t, s != "h" || m != true || e != nil,
+		"12", s, m, e)
+}
+
+func TestSeekNotFound(t *testing.T) {
+	json := "{\"a\":{\"b\":[1,{\"c/d\":2,\"e~f\":3},4],\"g\":5},\"h\":[6]}"
+	tests := []string{"/x", "/a/x", "/a/b/3", "/a/b/-", "/a/b/01", "/a/b/x", "/a/g/x", "/a/b/1/e~1f"}
+	for _, p := range tests {
+		v1, _ := Parse(strings.NewReader(json), 4)
+		_, m, e := v1.Seek(p)
+		assert(t, m != false || e != nil,
+			"1", p, m, e)
+		e = v1.Close()
+		assert(t, e != nil || v1.Status != Complete,
+			"2", p, e, v1.Status)
+	}
+	v1, _ := Parse(strings.NewReader(json), 4)
+	v2, m, e := v1.Seek("")
+	assert(t, v2.Type != Object || m != true || e != nil,
+		"3", v2.Type, m, e)
+	for _, p := range []string{"a", "/a~", "/a~2"} {
+		_, _, e = v1.Seek(p)
+		assert(t, e != ErrInvalidPointer,
+			"4", p, e)
+	}
+	v1, _ = Parse(strings.NewReader("1"), 4)
+	_, _, e = v1.Seek("/a")
+	assert(t, e == nil || e.Error() != "Method cannot be called on type Number, only on Array or Object",
+		"5", e)
+}
+
+func TestSeekAllocs(t *testing.T) {
+	json := "{\"a\":{\"b\":[1,{\"c/d\":2,\"e~f\":3},4],\"g\":5},\"h\":[6]}"
+	r := strings.NewReader(json)
+	p := NewParser(r, 16)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(json)
+		p.Reset(r)
+		v1, _ := p.Next()
+		v2, _, _ := v1.Seek("/a/b/1/e~0f")
+		v2.ValueNum()
+		v1.Close()
+	})
+	assert(t, allocs != 0,
+		"1", allocs)
+}
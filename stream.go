@@ -0,0 +1,125 @@
+package jsonmuncher
+
+import (
+	"context"
+	"io"
+)
+
+// Parser reads a sequence of top-level JSON values from a single stream, such
+// as newline-delimited JSON or concatenated JSON documents. Unlike Parse, which
+// drops its read buffer once the value has been read, a Parser keeps the same
+// buffer from one value to the next, so no input is lost between them.
+type Parser struct {
+	// Strict, if true, requires the stream to contain exactly one top-level
+	// value. Once that value has been read in its entirety, the remainder of the
+	// stream is checked, and any data other than whitespace is reported as an
+	// error by the call that finished reading the value.
+	Strict bool
+	// Limits, if set, bounds the input accepted by the Parser. MaxTotalBytes
+	// applies to the stream as a whole, rather than to each value.
+	Limits Limits
+	// TrackPath, if not nil, enables path tracking for each value read, as with
+	// ParseWithPath. The path is stored in this slice, up to its capacity.
+	TrackPath []byte
+	// Context, if not nil, stops the Parser once it is done, as with
+	// ParseContext. It is checked each time the read buffer is fed.
+	Context context.Context
+	// buf is the read buffer shared by every value read from this Parser.
+	buf buffer
+	// own is the slice created by NewParser for buf to read into. It is set
+	// aside while the Parser reads from a slice given to ResetBytes.
+	own []byte
+	// opts holds the options of buf, which always points to it, so that using
+	// them doesn't allocate.
+	opts options
+	// primed is true once the first chunk has been read into the buffer.
+	primed bool
+}
+
+// NewParser creates a Parser that reads from the given io.Reader. This
+// function also takes a size (in bytes) to use when creating the read buffer.
+func NewParser(r io.Reader, size int) *Parser {
+	p := &Parser{own: make([]byte, size)}
+	p.buf = streamBuffer(r, p.own)
+	return p
+}
+
+// Reset discards any remaining state and prepares the Parser to read from a new
+// io.Reader, reusing the existing read buffer. Any JsonValue previously read
+// from this Parser becomes invalid. A Parser can be reset any number of times,
+// which makes it suitable for reuse through a sync.Pool: once a Parser has been
+// created, parsing with it does not allocate.
+func (p *Parser) Reset(r io.Reader) {
+	p.buf = streamBuffer(r, p.own)
+	p.opts = options{}
+	p.primed = false
+}
+
+// ResetBytes is like Reset, but prepares the Parser to read from a slice that is
+// already held in memory, as with ParseBytes. The slice itself is used as the
+// read buffer, so the input is never copied, and the Parser's own buffer is kept
+// for the next call to Reset. The slice must not be modified until the Parser is
+// reset again. If it is 4 GiB or more in length, ErrInputTooLarge is returned,
+// and the Parser is left as it was. Limits.MaxTotalBytes applies to the slice
+// just as it does to a stream: anything past the limit is cut off, and reading
+// up to it returns ErrTotalLimit.
+func (p *Parser) ResetBytes(data []byte) error {
+	if uint64(len(data)) > 1<<32-1 {
+		return ErrInputTooLarge
+	}
+	p.buf = bytesBuffer(data)
+	p.opts = options{}
+	p.primed = false
+	return nil
+}
+
+// Next reads the next top-level value from the stream, and returns it as a
+// JsonValue. Any amount of whitespace is allowed before, between, and after the
+// values. The previous value must be fully read or closed before Next is
+// called, otherwise ErrWorkingChild is returned. When there are no more values
+// in the stream, io.EOF is returned.
+//
+// In strict mode, the first call to Next behaves like Parse, and every later
+// call returns io.EOF if the stream holds nothing but whitespace after the
+// first value, or an error otherwise.
+func (p *Parser) Next() (JsonValue, error) {
+	p.buf.strict = p.Strict
+	p.buf.opts = &p.opts
+	p.opts.limits = p.Limits
+	p.opts.ctx = p.Context
+	if p.TrackPath != nil {
+		startPath(&p.opts, p.TrackPath)
+	} else {
+		p.opts.path = nil
+	}
+	if !p.primed {
+		if p.buf.stream == nil && p.Limits.MaxTotalBytes != 0 {
+			// A slice given to ResetBytes is never fed, so the limit is
+			// applied to all of it here.
+			n, err := limitTotal(&p.buf, int(p.buf.erroffs), p.buf.readerr)
+			p.buf.erroffs, p.buf.readerr = uint32(n), err
+		}
+		_ = feedq(&p.buf) && feed(&p.buf)
+		next(&p.buf)
+		p.primed = true
+		if p.Strict {
+			return readTopLevel(&p.buf)
+		}
+	} else if p.buf.depth != 0 {
+		return JsonValue{}, ErrWorkingChild
+	} else if p.Strict {
+		err := readEnd(&p.buf)
+		if err != nil {
+			return JsonValue{}, err
+		}
+		return JsonValue{}, io.EOF
+	}
+	_, err := skipSpace(&p.buf)
+	if err != nil {
+		return JsonValue{}, err
+	}
+	if p.buf.err == io.EOF {
+		return JsonValue{}, io.EOF
+	}
+	return readValue(&p.buf)
+}
@@ -0,0 +1,221 @@
+package jsonmuncher
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestParserNext(t *testing.T) {
+	json := "{\"a\":1}\n[true,null]\n\n  \"str\"\r\n-12.5 false\n{}"
+	var buf [8]byte
+	p := NewParser(strings.NewReader(json), 4)
+	v, e := p.Next()
+	assert(t, v.Type != Object || e != nil,
+		"1", v.Type, e)
+	_, vn, mn, en := v.FindKey("a")
+	assert(t, vn.Type != Number || mn != true || en != nil,
+		"2", vn.Type, mn, en)
+	n, en := vn.ValueNum()
+	assert(t, n != 1 || en != nil,
+		"3", n, en)
+	_, e = p.Next()
+	assert(t, e != ErrWorkingChild,
+		"4", e)
+	e = v.Close()
+	assert(t, e != nil,
+		"5", e)
+	v, e = p.Next()
+	assert(t, v.Type != Array || e != nil,
+		"6", v.Type, e)
+	e = v.Close()
+	assert(t, e != nil,
+		"7", e)
+	v, e = p.Next()
+	assert(t, v.Type != String || e != nil,
+		"8", v.Type, e)
+	s, eof := v.Read(buf[:])
+	assert(t, eof != io.EOF || string(buf[:s]) != "str",
+		"9", string(buf[:s]), eof)
+	v, e = p.Next()
+	assert(t, v.Type != Number || e != nil,
+		"10", v.Type, e)
+	n, en = v.ValueNum()
+	assert(t, n != -12.5 || en != nil,
+		"11", n, en)
+	v, e = p.Next()
+	assert(t, v.Type != Bool || e != nil,
+		"12", v.Type, e)
+	v, e = p.Next()
+	assert(t, v.Type != Object || e != nil,
+		"13", v.Type, e)
+	_, e = v.NextKey()
+	assert(t, e != EndOfValue,
+		"14", e)
+	_, e = p.Next()
+	assert(t, e != io.EOF,
+		"15", e)
+	_, e = p.Next()
+	assert(t, e != io.EOF,
+		"16", e)
+}
+
+func TestParserEmpty(t *testing.T) {
+	p := NewParser(strings.NewReader(""), 16)
+	_, e := p.Next()
+	assert(t, e != io.EOF,
+		"1", e)
+	p = NewParser(strings.NewReader(" \n\t\r\n "), 2)
+	_, e = p.Next()
+	assert(t, e != io.EOF,
+		"2", e)
+	p = NewParser(strings.NewReader("[1]\n]"), 16)
+	v, _ := p.Next()
+	v.Close()
+	_, e = p.Next()
+	assert(t, e == nil || e.Error() != "Unexpected ']' at file offset 4, expected one of '{', '[', '\"', 'n', 't', 'f', '-', '0'-'9'",
+		"3", e)
+}
+
+func TestParserStrict(t *testing.T) {
+	var buf [8]byte
+	p := NewParser(strings.NewReader("{\"a\":[1]} \n"), 4)
+	p.Strict = true
+	v, e := p.Next()
+	assert(t, v.Type != Object || e != nil,
+		"1", v.Type, e)
+	e = v.Close()
+	assert(t, e != nil,
+		"2", e)
+	_, e = p.Next()
+	assert(t, e != io.EOF,
+		"3", e)
+	p = NewParser(strings.NewReader("{\"a\":[1]} {}"), 4)
+	p.Strict = true
+	v, _ = p.Next()
+	e = v.Close()
+	assert(t, v.Status != Incomplete || e == nil || e.Error() != "Unexpected '{' at file offset 10: only whitespace is allowed after the top-level value",
+		"4", v.Status, e)
+	_, e = p.Next()
+	assert(t, e == nil || e.Error() != "Unexpected '{' at file offset 10: only whitespace is allowed after the top-level value",
+		"5", e)
+	p = NewParser(strings.NewReader("\"str\"x"), 16)
+	p.Strict = true
+	v, _ = p.Next()
+	_, e = v.Read(buf[:])
+	assert(t, e == nil || e.Error() != "Unexpected 'x' at file offset 5: only whitespace is allowed after the top-level value",
+		"6", e)
+	p = NewParser(strings.NewReader("12 3"), 16)
+	p.Strict = true
+	v, _ = p.Next()
+	_, e = v.ValueNum()
+	assert(t, e == nil || e.Error() != "Unexpected '3' at file offset 3: only whitespace is allowed after the top-level value",
+		"7", e)
+	p = NewParser(strings.NewReader("[1]]"), 16)
+	p.Strict = true
+	v, _ = p.Next()
+	n, _ := v.NextValue()
+	n.Close()
+	_, e = v.NextValue()
+	assert(t, e == nil || e.Error() != "Unexpected ']' at file offset 3: only whitespace is allowed after the top-level value",
+		"8", e)
+	p = NewParser(strings.NewReader("truex"), 16)
+	p.Strict = true
+	_, e = p.Next()
+	assert(t, e == nil || e.Error() != "Unexpected 'x' at file offset 4: only whitespace is allowed after the top-level value",
+		"9", e)
+	p = NewParser(strings.NewReader("  "), 16)
+	p.Strict = true
+	_, e = p.Next()
+	assert(t, e == nil || e.Error() != "Unexpected EOF at file offset 2, expected one of '{', '[', '\"', 'n', 't', 'f', '-', '0'-'9'",
+		"10", e)
+}
+
+func TestParserReset(t *testing.T) {
+	var buf [8]byte
+	r := strings.NewReader("[1, 2] [3]")
+	p := NewParser(r, 4)
+	v, _ := p.Next()
+	v.NextValue()
+	r.Reset("\"foo\"")
+	p.Reset(r)
+	v, e := p.Next()
+	assert(t, v.Type != String || e != nil,
+		"1", v.Type, e)
+	s, eof := v.Read(buf[:])
+	assert(t, eof != io.EOF || string(buf[:s]) != "foo",
+		"2", string(buf[:s]), eof)
+	_, e = p.Next()
+	assert(t, e != io.EOF,
+		"3", e)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset("{\"a\":[1,2,3],\"b\":\"c\"}")
+		p.Reset(r)
+		v, _ := p.Next()
+		_, vk, _, _ := v.FindKey("b")
+		vk.Compare("c")
+		v.Close()
+	})
+	assert(t, allocs != 0,
+		"4", allocs)
+}
+
+func TestParserResetBytes(t *testing.T) {
+	var buf [16]byte
+	r := strings.NewReader("[1, 2]")
+	p := NewParser(r, 4)
+	json := []byte("{\"a\":[1,2,3],\"b\":\"long string\"}")
+	e := p.ResetBytes(json)
+	assert(t, e != nil,
+		"1", e)
+	v, e := p.Next()
+	assert(t, v.Type != Object || e != nil,
+		"2", v.Type, e)
+	_, vk, mk, ek := v.FindKey("b")
+	s, eof := vk.Read(buf[:])
+	assert(t, mk != true || ek != nil || eof != io.EOF || string(buf[:s]) != "long string",
+		"3", mk, ek, string(buf[:s]), eof)
+	v.Close()
+	_, e = p.Next()
+	assert(t, e != io.EOF,
+		"4", e)
+	// The Parser's own buffer is used again once it is reset to a stream.
+	p.Reset(r)
+	v, e = p.Next()
+	assert(t, v.Type != Array || e != nil,
+		"5", v.Type, e)
+	v.Close()
+	allocs := testing.AllocsPerRun(100, func() {
+		p.ResetBytes(json)
+		v, _ := p.Next()
+		_, vk, _, _ := v.FindKey("b")
+		vk.Compare("long string")
+		v.Close()
+	})
+	assert(t, allocs != 0,
+		"6", allocs)
+}
+
+func TestParserOptions(t *testing.T) {
+	var buf [16]byte
+	json := "{\"a\": [\"abc\", \"abcde\"]}"
+	r := strings.NewReader(json)
+	p := NewParser(r, 4)
+	p.Context = context.Background()
+	p.Limits = Limits{MaxStringBytes: 4}
+	p.TrackPath = make([]byte, 0, 16)
+	v1, _ := p.Next()
+	v2, _, _ := v1.Seek("/a/1")
+	_, e := v2.Read(buf[:])
+	assert(t, e != ErrStringLimit{14, 4, "/a/1"},
+		"1", e)
+	ctx, cancel := context.WithCancel(context.Background())
+	r.Reset(json)
+	p.Reset(&cancelReader{r, 2, cancel})
+	p.Context = ctx
+	v1, _ = p.Next()
+	e = v1.Close()
+	assert(t, e != context.Canceled || v1.Status != Incomplete,
+		"2", e, v1.Status)
+}
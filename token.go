@@ -0,0 +1,120 @@
+package jsonmuncher
+
+import (
+	"io"
+)
+
+// TokenKind represents the kind of a Token.
+type TokenKind byte
+
+const (
+	// TokenObjectStart is the opening brace of an Object.
+	TokenObjectStart TokenKind = iota
+	// TokenObjectEnd is the closing brace of an Object.
+	TokenObjectEnd
+	// TokenArrayStart is the opening bracket of an Array.
+	TokenArrayStart
+	// TokenArrayEnd is the closing bracket of an Array.
+	TokenArrayEnd
+	// TokenKey is a key of an Object.
+	TokenKey
+	// TokenValue is a Null, Bool, Number, or String value.
+	TokenValue
+)
+
+// Token is a single token produced by a Tokenizer.
+type Token struct {
+	// Kind is the kind of this token.
+	Kind TokenKind
+	// Depth is the number of Objects and Arrays that enclose this token. The
+	// start and end of the top-level value are at depth 0.
+	Depth int
+	// Value is the key or value, if this is a TokenKey or TokenValue, and nil
+	// otherwise. A key or String can be read as a stream, using Read(). Value
+	// is only valid until the next call to Token().
+	Value *JsonValue
+}
+
+// Tokenizer splits a value into a flat sequence of tokens, in the style of
+// encoding/json's Decoder.Token(). This is useful when porting code written
+// against that API, since the nesting of JsonValues doesn't need to be
+// reflected in the control flow.
+type Tokenizer struct {
+	// root is the value being tokenized, until its first token is produced.
+	root JsonValue
+	// started is true once the first token has been produced.
+	started bool
+	// cur is the key or value of the last token produced.
+	cur JsonValue
+	// stack holds the Objects and Arrays that are currently open.
+	stack []JsonValue
+	// stackbuf is the initial backing array of stack.
+	stackbuf [8]JsonValue
+}
+
+// NewTokenizer creates a Tokenizer that produces the tokens of the given value.
+func NewTokenizer(v JsonValue) *Tokenizer {
+	t := &Tokenizer{root: v}
+	t.stack = t.stackbuf[:0]
+	return t
+}
+
+// Token returns the next token. Any part of the previous token's Value that
+// hasn't been read is discarded first. Once the last token of the value has
+// been produced, io.EOF is returned.
+func (t *Tokenizer) Token() (Token, error) {
+	if t.cur.Status == Working {
+		err := t.cur.Close()
+		if err != nil {
+			return Token{}, err
+		}
+	}
+	if !t.started {
+		t.started = true
+		return t.push(t.root), nil
+	}
+	n := len(t.stack)
+	if n == 0 {
+		return Token{}, io.EOF
+	}
+	top := &t.stack[n-1]
+	var val JsonValue
+	var err error
+	if top.Type == Object && top.keynext {
+		val, err = top.NextKey()
+		if err == nil {
+			t.cur = val
+			return Token{TokenKey, n, &t.cur}, nil
+		}
+	} else {
+		val, err = top.NextValue()
+		if err == nil {
+			return t.push(val), nil
+		}
+	}
+	if err != EndOfValue {
+		return Token{}, err
+	}
+	kind := TokenArrayEnd
+	if top.Type == Object {
+		kind = TokenObjectEnd
+	}
+	t.stack = t.stack[:n-1]
+	return Token{kind, n - 1, nil}, nil
+}
+
+// push produces the token for the start of a value. If the value is an Object
+// or an Array, it is pushed onto the stack.
+func (t *Tokenizer) push(val JsonValue) Token {
+	depth := len(t.stack)
+	switch val.Type {
+	case Object:
+		t.stack = append(t.stack, val)
+		return Token{TokenObjectStart, depth, nil}
+	case Array:
+		t.stack = append(t.stack, val)
+		return Token{TokenArrayStart, depth, nil}
+	}
+	t.cur = val
+	return Token{TokenValue, depth, &t.cur}
+}
@@ -0,0 +1,77 @@
+package jsonmuncher
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTokenizer(t *testing.T) {
+	json := "{\"a\": [1, \"two\", true, null], \"b\": {}, \"c\": \"skipped\", \"d\": [[]]}"
+	v1, _ := Parse(strings.NewReader(json), 4)
+	tk := NewTokenizer(v1)
+	var buf [8]byte
+	var out []string
+	for {
+		tok, e := tk.Token()
+		if e == io.EOF {
+			break
+		}
+		assert(t, e != nil,
+			"1", e)
+		s := strings.Repeat(" ", tok.Depth)
+		switch tok.Kind {
+		case TokenObjectStart:
+			s += "{"
+		case TokenObjectEnd:
+			s += "}"
+		case TokenArrayStart:
+			s += "["
+		case TokenArrayEnd:
+			s += "]"
+		case TokenKey:
+			n, _ := tok.Value.Read(buf[:])
+			s += string(buf[:n]) + ":"
+		case TokenValue:
+			switch tok.Value.Type {
+			case Number:
+				n, _ := tok.Value.ValueNumRaw(nil)
+				s += string(n)
+			case String:
+				if tok.Depth == 2 {
+					n, _ := tok.Value.Read(buf[:2])
+					s += string(buf[:n])
+				}
+			case Bool:
+				s += "bool"
+			case Null:
+				s += "null"
+			}
+		}
+		out = append(out, s)
+	}
+	res := strings.Join(out, "|")
+	assert(t, res != "{| a:| [|  1|  tw|  bool|  null| ]| b:| {| }| c:| | d:| [|  [|  ]| ]|}",
+		"2", res)
+	assert(t, v1.buffer.depth != 0,
+		"3", v1.buffer.depth)
+	_, e := tk.Token()
+	assert(t, e != io.EOF,
+		"4", e)
+	v1, _ = Parse(strings.NewReader("12"), 4)
+	tk = NewTokenizer(v1)
+	tok, e := tk.Token()
+	n, _ := tok.Value.ValueNum()
+	assert(t, tok.Kind != TokenValue || tok.Depth != 0 || n != 12 || e != nil,
+		"5", tok.Kind, tok.Depth, n, e)
+	_, e = tk.Token()
+	assert(t, e != io.EOF,
+		"6", e)
+	v1, _ = Parse(strings.NewReader("[1 2]"), 4)
+	tk = NewTokenizer(v1)
+	tk.Token()
+	tk.Token()
+	_, e = tk.Token()
+	assert(t, e == nil || e.Error() != "Unexpected '2' at file offset 3, expected one of ',', ']'",
+		"7", e)
+}
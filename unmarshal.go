@@ -0,0 +1,623 @@
+package jsonmuncher
+
+import (
+	"encoding"
+	"encoding/base64"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Unmarshaler is implemented by types that can read themselves from the raw
+// text of a JSON value. It matches json.Unmarshaler from encoding/json.
+type Unmarshaler interface {
+	UnmarshalJSON([]byte) error
+}
+
+var (
+	unmarshalerType     = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// structInfo describes how the keys of an Object map to the fields of a struct
+// type. The names are sorted for compareFoldRead, and fields is in the same
+// order. Names that are equal under case folding are ordered as their fields
+// are in the struct, and fold is set if there are any, since then a key has to
+// be read into memory to find out which of them it matches exactly.
+type structInfo struct {
+	names  []string
+	fields []fieldInfo
+	fold   bool
+}
+
+// fieldInfo describes a struct field. index is the path of field indices to it,
+// through any embedded structs, and quoted is set by the ",string" option.
+type fieldInfo struct {
+	index  []int
+	quoted bool
+}
+
+// structCache holds the structInfo for each struct type that has been seen, so
+// that the reflection is only done once per type.
+var structCache sync.Map
+
+// cachedStruct returns the structInfo for a struct type.
+func cachedStruct(t reflect.Type) *structInfo {
+	if info, ok := structCache.Load(t); ok {
+		return info.(*structInfo)
+	}
+	info, _ := structCache.LoadOrStore(t, buildStruct(t))
+	return info.(*structInfo)
+}
+
+// fieldCand is a field that may be decoded into, before conflicts between the
+// fields of embedded structs are settled.
+type fieldCand struct {
+	name   string
+	tagged bool
+	fieldInfo
+}
+
+// buildStruct works out the fields of a struct type, following the rules of
+// encoding/json: fields of embedded structs are promoted, and where several
+// fields have the same name, the least deeply nested one is used, then the
+// tagged one. If that still leaves more than one, none of them are used.
+func buildStruct(t reflect.Type) *structInfo {
+	var cands []fieldCand
+	collectFields(t, nil, map[reflect.Type]bool{}, &cands)
+	sort.SliceStable(cands, func(i, j int) bool {
+		a, b := &cands[i], &cands[j]
+		if a.name != b.name {
+			return a.name < b.name
+		} else if len(a.index) != len(b.index) {
+			return len(a.index) < len(b.index)
+		}
+		return a.tagged && !b.tagged
+	})
+	var keep []fieldCand
+	for i := 0; i < len(cands); {
+		j := i + 1
+		for j < len(cands) && cands[j].name == cands[i].name {
+			j++
+		}
+		if j == i+1 || len(cands[i+1].index) > len(cands[i].index) ||
+			cands[i].tagged && !cands[i+1].tagged {
+			keep = append(keep, cands[i])
+		}
+		i = j
+	}
+	sort.SliceStable(keep, func(i, j int) bool {
+		a, b := &keep[i], &keep[j]
+		if foldLess(a.name, b.name) || foldLess(b.name, a.name) {
+			return foldLess(a.name, b.name)
+		}
+		return indexLess(a.index, b.index)
+	})
+	info := &structInfo{}
+	for i := range keep {
+		if i > 0 && !foldLess(keep[i-1].name, keep[i].name) {
+			info.fold = true
+		}
+		info.names = append(info.names, keep[i].name)
+		info.fields = append(info.fields, keep[i].fieldInfo)
+	}
+	return info
+}
+
+// indexLess reports whether the field with index a comes before the one with
+// index b in the struct.
+func indexLess(a []int, b []int) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// findField returns the field that a key matches, and false if there is none.
+// A key matches a field name that is equal to it under case folding, preferring
+// one that is equal exactly, as with encoding/json.
+func findField(key *JsonValue, info *structInfo) (*fieldInfo, bool, error) {
+	if !info.fold {
+		name, match, err := compareFoldRead(key, info.names)
+		if !match || err != nil {
+			return nil, false, err
+		}
+		i := sort.Search(len(info.names), func(i int) bool {
+			return !foldLess(info.names[i], name)
+		})
+		return &info.fields[i], true, nil
+	}
+	s, err := readText(key)
+	if err != nil {
+		return nil, false, err
+	}
+	first := -1
+	for i, name := range info.names {
+		if name == s {
+			return &info.fields[i], true, nil
+		} else if n, ok := foldPrefix(s, name); ok && n == len(s) && first < 0 {
+			first = i
+		}
+	}
+	if first < 0 {
+		return nil, false, nil
+	}
+	return &info.fields[first], true, nil
+}
+
+// collectFields adds the fields of a struct type, and those of its embedded
+// structs, to the list of candidates. The types being visited are tracked, so a
+// struct that embeds itself doesn't recurse forever.
+func collectFields(t reflect.Type, index []int, visiting map[reflect.Type]bool, cands *[]fieldCand) {
+	if visiting[t] {
+		return
+	}
+	visiting[t] = true
+	defer delete(visiting, t)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		idx := append(append([]int(nil), index...), i)
+		ft := f.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			// An unexported embedded pointer can't be allocated, so its fields
+			// can't be set.
+			if f.IsExported() || f.Type.Kind() != reflect.Pointer {
+				collectFields(ft, idx, visiting, cands)
+			}
+			continue
+		} else if !f.IsExported() {
+			continue
+		}
+		cand := fieldCand{name: name, tagged: name != ""}
+		if name == "" {
+			cand.name = f.Name
+		}
+		cand.index = idx
+		for opts != "" {
+			var opt string
+			opt, opts, _ = strings.Cut(opts, ",")
+			if opt == "string" {
+				switch ft.Kind() {
+				case reflect.Bool, reflect.String,
+					reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+					reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+					reflect.Uintptr, reflect.Float32, reflect.Float64:
+					cand.quoted = true
+				}
+			}
+		}
+		*cands = append(*cands, cand)
+	}
+}
+
+// Unmarshal reads a value into the Go value that dst points to, in the same way
+// as json.Unmarshal from encoding/json. Struct fields are matched using their
+// "json" tags, including the ",string" option; other options, such as
+// ",omitempty", only matter when writing JSON, so they are ignored. As with
+// encoding/json, keys match field names when case is ignored, and an exact match
+// is preferred. Keys that don't match any field are discarded without being read
+// into memory, unless the struct has field names that differ only in case. The
+// metadata for each struct type is worked out once, and cached. The value is
+// consumed through a copy, so v must not be used once Unmarshal returns.
+//
+// Types that implement Unmarshaler are given the raw text of their values, and
+// types that implement encoding.TextUnmarshaler are given the contents of
+// Strings. Null sets pointers, slices, maps and interfaces to nil, and leaves
+// anything else as it was. Unmarshal stops at the first error, and the parts of
+// dst that have already been filled in are left as they are. Syntax errors in
+// values read with the ",string" option give offsets within the String.
+func Unmarshal(v JsonValue, dst any) error {
+	rv := reflect.ValueOf(dst)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrInvalidTarget
+	}
+	return unmarshal(&v, rv.Elem(), false)
+}
+
+// unmarshal reads a value into a Go value. If quoted is set, a Bool, Number, or
+// String is expected to be held in a String, as with the ",string" option.
+func unmarshal(data *JsonValue, v reflect.Value, quoted bool) error {
+	if data.Type == Null {
+		switch v.Kind() {
+		case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+			v.Set(reflect.Zero(v.Type()))
+		}
+		return nil
+	}
+	for {
+		if v.Kind() != reflect.Pointer && v.CanAddr() {
+			pt := reflect.PointerTo(v.Type())
+			if pt.Implements(unmarshalerType) {
+				raw, err := appendRaw(nil, data)
+				if err != nil {
+					return err
+				}
+				return v.Addr().Interface().(Unmarshaler).UnmarshalJSON(raw)
+			} else if data.Type == String && pt.Implements(textUnmarshalerType) {
+				s, err := readText(data)
+				if err != nil {
+					return err
+				}
+				return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+			}
+		}
+		if v.Kind() != reflect.Pointer {
+			break
+		} else if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if quoted && data.Type == String {
+		s, err := readText(data)
+		if err != nil {
+			return err
+		}
+		inner, err := ParseBytes([]byte(s))
+		if err == nil {
+			err = unmarshal(&inner, v, false)
+		}
+		if err == nil {
+			err = inner.Finish()
+		}
+		return err
+	}
+	if v.Kind() == reflect.Interface {
+		if v.NumMethod() != 0 {
+			return ErrUnmarshalType{data.Type, v.Type().String(), data.Path()}
+		}
+		x, err := valueAny(data)
+		if err != nil {
+			return err
+		} else if x == nil {
+			v.Set(reflect.Zero(v.Type()))
+		} else {
+			v.Set(reflect.ValueOf(x))
+		}
+		return nil
+	}
+	switch data.Type {
+	case Bool:
+		if v.Kind() != reflect.Bool {
+			break
+		}
+		v.SetBool(data.boolval)
+		return nil
+	case Number:
+		return unmarshalNumber(data, v)
+	case String:
+		if v.Kind() == reflect.String {
+			s, err := readText(data)
+			v.SetString(s)
+			return err
+		} else if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			s, err := readText(data)
+			if err != nil {
+				return err
+			}
+			b, err := base64.StdEncoding.DecodeString(s)
+			v.SetBytes(b)
+			return err
+		}
+	case Array:
+		switch v.Kind() {
+		case reflect.Slice:
+			return unmarshalSlice(data, v)
+		case reflect.Array:
+			return unmarshalArray(data, v)
+		}
+	case Object:
+		switch v.Kind() {
+		case reflect.Struct:
+			return unmarshalStruct(data, v)
+		case reflect.Map:
+			return unmarshalMap(data, v)
+		}
+	}
+	return ErrUnmarshalType{data.Type, v.Type().String(), data.Path()}
+}
+
+// unmarshalNumber reads a Number into an integer or floating point Go value.
+func unmarshalNumber(data *JsonValue, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := data.ValueInt64()
+		if err != nil {
+			return err
+		} else if v.OverflowInt(n) {
+			return ErrNumRange{v.Type().String(), false, data.Path()}
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr:
+		n, err := data.ValueUint64()
+		if err != nil {
+			return err
+		} else if v.OverflowUint(n) {
+			return ErrNumRange{v.Type().String(), false, data.Path()}
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := data.ValueNum()
+		if err != nil {
+			return err
+		} else if v.OverflowFloat(n) {
+			return ErrNumRange{v.Type().String(), false, data.Path()}
+		}
+		v.SetFloat(n)
+	default:
+		return ErrUnmarshalType{Number, v.Type().String(), data.Path()}
+	}
+	return nil
+}
+
+// unmarshalElem reads an element or member into a Go value, and discards
+// whatever is left of it.
+func unmarshalElem(val *JsonValue, v reflect.Value, quoted bool) error {
+	err := unmarshal(val, v, quoted)
+	if err != nil {
+		return err
+	}
+	return val.Close()
+}
+
+// unmarshalSlice reads an Array into a slice, reusing its backing array.
+func unmarshalSlice(data *JsonValue, v reflect.Value) error {
+	// val is declared outside the loop, so it can stay on the stack although
+	// its address is passed back into unmarshal.
+	var val JsonValue
+	i := 0
+	for ; ; i++ {
+		var err error
+		val, err = data.NextValue()
+		if err == EndOfValue {
+			break
+		} else if err != nil {
+			return err
+		}
+		if i < v.Cap() {
+			v.SetLen(i + 1)
+		} else {
+			v.Set(reflect.Append(v, reflect.Zero(v.Type().Elem())))
+		}
+		// Elements left over from the slice's previous contents are cleared
+		// first, as they would be by append.
+		v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+		err = unmarshalElem(&val, v.Index(i), false)
+		if err != nil {
+			return err
+		}
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeSlice(v.Type(), 0, 0))
+	} else {
+		v.SetLen(i)
+	}
+	return nil
+}
+
+// unmarshalArray reads an Array into a Go array. Extra elements are discarded,
+// and if there are too few, the rest of the Go array is zeroed.
+func unmarshalArray(data *JsonValue, v reflect.Value) error {
+	var val JsonValue
+	i := 0
+	for ; ; i++ {
+		var err error
+		val, err = data.NextValue()
+		if err == EndOfValue {
+			break
+		} else if err != nil {
+			return err
+		} else if i >= v.Len() {
+			err = val.Close()
+		} else {
+			err = unmarshalElem(&val, v.Index(i), false)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	for ; i < v.Len(); i++ {
+		v.Index(i).Set(reflect.Zero(v.Type().Elem()))
+	}
+	return nil
+}
+
+// unmarshalStruct reads an Object into a struct. Keys that don't match a field
+// are discarded.
+func unmarshalStruct(data *JsonValue, v reflect.Value) error {
+	info := cachedStruct(v.Type())
+	var val JsonValue
+	for {
+		key, err := data.NextKey()
+		if err == EndOfValue {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if len(info.names) == 0 {
+			err = key.Close()
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		f, match, err := findField(&key, info)
+		if err != nil {
+			return err
+		} else if !match {
+			// The value is discarded by the next call to NextKey.
+			continue
+		}
+		val, err = data.NextValue()
+		if err != nil {
+			return err
+		}
+		err = unmarshalElem(&val, fieldByIndex(v, f.index), f.quoted)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// fieldByIndex returns a struct field by its path of field indices, allocating
+// any embedded structs along the way that are nil pointers.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// unmarshalMap reads an Object into a map. The map's keys must be strings,
+// integers, or implement encoding.TextUnmarshaler.
+func unmarshalMap(data *JsonValue, v reflect.Value) error {
+	t := v.Type()
+	kt := t.Key()
+	switch kt.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Uintptr:
+	default:
+		if !reflect.PointerTo(kt).Implements(textUnmarshalerType) {
+			return ErrUnmarshalType{Object, t.String(), data.Path()}
+		}
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(t))
+	}
+	var val JsonValue
+	for {
+		key, err := data.NextKey()
+		if err == EndOfValue {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		s, err := readText(&key)
+		if err != nil {
+			return err
+		}
+		kv := reflect.New(kt).Elem()
+		if reflect.PointerTo(kt).Implements(textUnmarshalerType) {
+			err = kv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		} else {
+			err = mapKey(&key, s, kv)
+		}
+		if err != nil {
+			return err
+		}
+		val, err = data.NextValue()
+		if err != nil {
+			return err
+		}
+		ev := reflect.New(t.Elem()).Elem()
+		err = unmarshalElem(&val, ev, false)
+		if err != nil {
+			return err
+		}
+		v.SetMapIndex(kv, ev)
+	}
+}
+
+// mapKey converts a key, which has been read as s, to a map key of string or
+// integer kind.
+func mapKey(key *JsonValue, s string, kv reflect.Value) error {
+	switch kv.Kind() {
+	case reflect.String:
+		kv.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || kv.OverflowInt(n) {
+			return ErrUnmarshalType{String, kv.Type().String(), key.Path()}
+		}
+		kv.SetInt(n)
+	default:
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil || kv.OverflowUint(n) {
+			return ErrUnmarshalType{String, kv.Type().String(), key.Path()}
+		}
+		kv.SetUint(n)
+	}
+	return nil
+}
+
+// valueAny reads a value into the Go type encoding/json would use for an empty
+// interface: nil, bool, float64, string, []any, or map[string]any.
+func valueAny(data *JsonValue) (any, error) {
+	switch data.Type {
+	case Bool:
+		return data.boolval, nil
+	case Number:
+		return data.ValueNum()
+	case String:
+		return readText(data)
+	case Array:
+		arr := []any{}
+		var val JsonValue
+		for {
+			var err error
+			val, err = data.NextValue()
+			if err == EndOfValue {
+				return arr, nil
+			} else if err != nil {
+				return nil, err
+			}
+			x, err := valueAny(&val)
+			if err == nil {
+				err = val.Close()
+			}
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, x)
+		}
+	case Object:
+		obj := map[string]any{}
+		var val JsonValue
+		for {
+			key, err := data.NextKey()
+			if err == EndOfValue {
+				return obj, nil
+			} else if err != nil {
+				return nil, err
+			}
+			k, err := readText(&key)
+			if err != nil {
+				return nil, err
+			}
+			val, err = data.NextValue()
+			if err != nil {
+				return nil, err
+			}
+			x, err := valueAny(&val)
+			if err == nil {
+				err = val.Close()
+			}
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = x
+		}
+	}
+	return nil, nil
+}
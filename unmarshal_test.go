@@ -0,0 +1,219 @@
+package jsonmuncher
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type umBase struct {
+	ID      int    `json:"id"`
+	Created string `json:"created,omitempty"`
+}
+
+type umRecord struct {
+	umBase
+	Name  string
+	Tags  []string        `json:"tags"`
+	Score float64         `json:"score,string"`
+	Big   uint64          `json:",string"`
+	Skip  int             `json:"-"`
+	Flag  *bool           `json:"flag"`
+	Pos   [2]int8         `json:"pos"`
+	Attrs map[string]any  `json:"attrs"`
+	Count map[int]uint16  `json:"count"`
+	When  time.Time       `json:"when"`
+	Raw   umRaw           `json:"raw"`
+	Data  []byte          `json:"data"`
+	Any   any             `json:"any"`
+	Ptrs  []*umBase       `json:"ptrs"`
+	Empty map[string]bool `json:"empty"`
+	hide  int
+}
+
+type umRaw struct {
+	text string
+}
+
+func (r *umRaw) UnmarshalJSON(b []byte) error {
+	r.text = string(b)
+	return nil
+}
+
+func TestUnmarshal(t *testing.T) {
+	json := `{"id": 7, "Name": "x\"y", "ignored": {"a": [1, "}"]}, "tags": ["a", "b"],
+		"score": "1.5", "Big": "18446744073709551615", "Skip": 3, "flag": true,
+		"pos": [1, -2, 3], "attrs": {"a": [1, "s", null, false], "b": {}}, "count": {"3": 4},
+		"when": "2024-01-02T03:04:05Z", "raw": [1, {"a" : 2}], "data": "aGk=",
+		"any": 12, "ptrs": [{"id": 1}, null], "empty": null, "hide": 1}`
+	flag := true
+	when, _ := time.Parse(time.RFC3339, "2024-01-02T03:04:05Z")
+	expect := umRecord{
+		umBase: umBase{ID: 7},
+		Name:   "x\"y",
+		Tags:   []string{"a", "b"},
+		Score:  1.5,
+		Big:    18446744073709551615,
+		Flag:   &flag,
+		Pos:    [2]int8{1, -2},
+		Attrs:  map[string]any{"a": []any{1.0, "s", nil, false}, "b": map[string]any{}},
+		Count:  map[int]uint16{3: 4},
+		When:   when,
+		Raw:    umRaw{`[1, {"a" : 2}]`},
+		Data:   []byte("hi"),
+		Any:    12.0,
+		Ptrs:   []*umBase{{ID: 1}, nil},
+	}
+	for size := 1; size <= len(json); size++ {
+		var dst umRecord
+		dst.Tags = []string{"old", "old", "old"}
+		dst.Empty = map[string]bool{"x": true}
+		val, _ := Parse(strings.NewReader(json), size)
+		err := Unmarshal(val, &dst)
+		assert(t, err != nil || !reflect.DeepEqual(dst, expect),
+			"1", size, err, dst)
+		err = val.Finish()
+		assert(t, err != nil,
+			"2", size, err)
+	}
+}
+
+func TestUnmarshalElements(t *testing.T) {
+	type P struct {
+		A int `json:"a"`
+	}
+	var ps []P
+	val, _ := Parse(strings.NewReader(`[{"a": 1}, {"a": 2, "b": [3]}]`), 4)
+	for elem, err := range val.Elements() {
+		var p P
+		if err == nil {
+			err = Unmarshal(*elem, &p)
+		}
+		assert(t, err != nil,
+			"1", err)
+		ps = append(ps, p)
+	}
+	err := val.Finish()
+	assert(t, err != nil || len(ps) != 2 || ps[1].A != 2,
+		"2", err, ps)
+	ps = ps[:0]
+	val, _ = Parse(strings.NewReader(`{"x": {"a": 3}, "y": 4, "z": {"a": 5}}`), 4)
+	err = val.EachKey([]string{"z", "x"}, func(idx int, v *JsonValue) error {
+		var p P
+		err := Unmarshal(*v, &p)
+		ps = append(ps, p)
+		return err
+	})
+	assert(t, err != nil || len(ps) != 2 || ps[0].A != 3 || ps[1].A != 5,
+		"3", err, ps)
+}
+
+func TestUnmarshalConflicts(t *testing.T) {
+	type A struct{ X, Y, Z int }
+	type B struct {
+		X int
+		Y int `json:"Y"`
+	}
+	type C struct {
+		A
+		B
+		Z int
+	}
+	json := `{"X": 1, "Y": 2, "Z": 3}`
+	var c C
+	val, _ := Parse(strings.NewReader(json), 4)
+	err := Unmarshal(val, &c)
+	assert(t, err != nil || c != C{A{0, 0, 0}, B{0, 2}, 3},
+		"1", err, c)
+	type D struct {
+		*D
+		N int
+	}
+	var d D
+	val, _ = Parse(strings.NewReader(`{"N": 1}`), 4)
+	err = Unmarshal(val, &d)
+	assert(t, err != nil || d.N != 1 || d.D != nil,
+		"2", err, d)
+}
+
+func TestUnmarshalFold(t *testing.T) {
+	type S struct {
+		Name  string
+		Score int `json:"score"`
+		A     int `json:"ab"`
+		B     int `json:"AB"`
+	}
+	json := `{"NAME": "x", "Score": 2, "aB": 3, "AB": 4}`
+	var s S
+	val, _ := Parse(strings.NewReader(json), 4)
+	err := Unmarshal(val, &s)
+	assert(t, err != nil || s != S{"x", 2, 3, 4},
+		"1", err, s)
+	s = S{}
+	val, _ = Parse(strings.NewReader(`{"ab": 1, "Ab": 2}`), 4)
+	err = Unmarshal(val, &s)
+	assert(t, err != nil || s != S{"", 0, 2, 0},
+		"2", err, s)
+}
+
+func TestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		json string
+		dst  any
+		err  string
+	}{
+		{`"x"`, new(int), "Cannot unmarshal String into Go value of type int"},
+		{`300`, new(int8), "Number is out of range for int8"},
+		{`-1`, new(uint), "Number is out of range for uint64"},
+		{`1.5`, new(int), "Number is not an integer, cannot convert to int64"},
+		{`1e39`, new(float32), "Number is out of range for float32"},
+		{`{"a": 1}`, new([]int), "Cannot unmarshal Object into Go value of type []int"},
+		{`{"a": 1}`, new(map[bool]int), "Cannot unmarshal Object into Go value of type map[bool]int"},
+		{`{"a": 1}`, new(map[int]int), "Cannot unmarshal String into Go value of type int"},
+		{`[true]`, new([]string), "Cannot unmarshal Bool into Go value of type string"},
+		{`1`, new(error), "Cannot unmarshal Number into Go value of type error"},
+		{`{"score": "1x"}`, new(umRecord), "Unexpected 'x' at file offset 1: only whitespace is allowed after the top-level value"},
+		{`[1, 2 3]`, new([]int), "Unexpected '3' at file offset 6, expected one of ',', ']'"},
+	}
+	for i, test := range tests {
+		val, _ := Parse(strings.NewReader(test.json), 4)
+		err := Unmarshal(val, test.dst)
+		assert(t, err == nil || err.Error() != test.err,
+			"1", i, err)
+	}
+	val, _ := Parse(strings.NewReader("1"), 4)
+	var n int
+	err := Unmarshal(val, n)
+	assert(t, err != ErrInvalidTarget,
+		"2", err)
+	err = Unmarshal(val, (*int)(nil))
+	assert(t, err != ErrInvalidTarget,
+		"3", err)
+	val, _ = ParseWithPath(strings.NewReader(`{"a": [1, "x"]}`), 4, make([]byte, 0, 16))
+	var m map[string][]int
+	err = Unmarshal(val, &m)
+	assert(t, err == nil || err.Error() != "Cannot unmarshal String into Go value of type int (path /a/1)",
+		"4", err)
+}
+
+func TestUnmarshalAllocs(t *testing.T) {
+	type S struct {
+		A int     `json:"a"`
+		B float64 `json:"b"`
+		C bool    `json:"c"`
+	}
+	json := `{"a": 1, "unknown": "some long string that is never read", "b": 2.5,
+		"other": {"x": [1, 2, 3]}, "c": true}`
+	r := strings.NewReader(json)
+	p := NewParser(r, 16)
+	var s S
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(json)
+		p.Reset(r)
+		v, _ := p.Next()
+		Unmarshal(v, &s)
+	})
+	assert(t, allocs != 0 || s != S{1, 2.5, true},
+		"1", allocs, s)
+}
@@ -0,0 +1,163 @@
+package jsonmuncher
+
+import (
+	"errors"
+	"io"
+)
+
+// SkipValue can be returned by a Handler to skip part of the document. When it
+// is returned from BeginObject or BeginArray, the entire Object or Array is
+// discarded, and End isn't called for it. When it is returned from Key, the
+// value associated with that key is discarded. Anywhere else, it has no effect.
+var SkipValue = errors.New("Skip this value")
+
+// Handler receives the events produced by Walk, in the order the corresponding
+// values appear in the stream. If any method returns an error other than
+// SkipValue, the walk stops and the error is returned.
+//
+// The io.Reader passed to Key and String, and the JsonValue passed to Number,
+// must not be used once the method returns; anything they haven't read is
+// discarded.
+type Handler interface {
+	// BeginObject is called at the start of an Object. It is followed by a call
+	// to Key and the events for the associated value, for each pair in the
+	// Object, and then by a call to End.
+	BeginObject() error
+	// Key is called with each key of an Object.
+	Key(r io.Reader) error
+	// BeginArray is called at the start of an Array. It is followed by the
+	// events for each element of the Array, and then by a call to End.
+	BeginArray() error
+	// End is called at the end of an Object or Array.
+	End() error
+	// String is called with the contents of a String.
+	String(r io.Reader) error
+	// Number is called with a Number, which can be read by any of the Number
+	// methods of JsonValue.
+	Number(v *JsonValue) error
+	// Bool is called with the value of a Bool.
+	Bool(b bool) error
+	// Null is called for a Null.
+	Null() error
+}
+
+// Walk reads a value in its entirety, reporting its contents to the given
+// Handler as a series of events. The document is read as a stream, with the
+// same machinery as NextKey(), NextValue(), and Read(), so memory use depends
+// only on how deeply the document is nested, never on its size. The value is
+// consumed by the walk, so v must not be used once Walk returns.
+func Walk(v JsonValue, h Handler) error {
+	w := walker{h: h}
+	return w.walk(&v)
+}
+
+// walker holds the state of a walk. Keys, Strings, and Numbers are the only
+// values passed to the Handler, and no more than one of them is open at a time,
+// so they share a single slot. Objects and Arrays are never passed to the
+// Handler, so they stay on the stack, one for each level of nesting.
+type walker struct {
+	h    Handler
+	leaf JsonValue
+}
+
+// walk reports the events for a single value.
+func (w *walker) walk(v *JsonValue) error {
+	var err error
+	switch v.Type {
+	case Null:
+		err = w.h.Null()
+	case Bool:
+		err = w.h.Bool(v.boolval)
+	case Number:
+		w.leaf = *v
+		err = w.h.Number(&w.leaf)
+		v = &w.leaf
+	case String:
+		w.leaf = *v
+		err = w.h.String(&w.leaf)
+		v = &w.leaf
+	case Array:
+		return w.walkArray(v)
+	case Object:
+		return w.walkObject(v)
+	}
+	if err != nil && err != SkipValue {
+		return err
+	}
+	return v.Close()
+}
+
+// walkArray reports the events for an Array.
+func (w *walker) walkArray(v *JsonValue) error {
+	err := w.h.BeginArray()
+	if err == SkipValue {
+		return v.Close()
+	} else if err != nil {
+		return err
+	}
+	var elem JsonValue
+	for {
+		elem, err = v.NextValue()
+		if err == EndOfValue {
+			break
+		} else if err != nil {
+			return err
+		}
+		err = w.walk(&elem)
+		if err != nil {
+			return err
+		}
+	}
+	return w.end()
+}
+
+// walkObject reports the events for an Object.
+func (w *walker) walkObject(v *JsonValue) error {
+	err := w.h.BeginObject()
+	if err == SkipValue {
+		return v.Close()
+	} else if err != nil {
+		return err
+	}
+	var val JsonValue
+	for {
+		w.leaf, err = v.NextKey()
+		if err == EndOfValue {
+			break
+		} else if err != nil {
+			return err
+		}
+		skip := false
+		err = w.h.Key(&w.leaf)
+		if err == SkipValue {
+			skip = true
+		} else if err != nil {
+			return err
+		}
+		err = w.leaf.Close()
+		if err != nil {
+			return err
+		} else if skip {
+			// The value is discarded by the next call to NextKey.
+			continue
+		}
+		val, err = v.NextValue()
+		if err != nil {
+			return err
+		}
+		err = w.walk(&val)
+		if err != nil {
+			return err
+		}
+	}
+	return w.end()
+}
+
+// end reports the end of an Object or Array.
+func (w *walker) end() error {
+	err := w.h.End()
+	if err == SkipValue {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+package jsonmuncher
+
+import (
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// printer is a Handler that writes the document back out as compact JSON. It
+// skips any key that starts with "skip", and writes null in place of any Array
+// that follows the key "noarray".
+type printer struct {
+	bld     strings.Builder
+	ends    []byte
+	comma   bool
+	noarray bool
+}
+
+func (p *printer) value(s string) {
+	if p.comma {
+		p.bld.WriteByte(',')
+	}
+	p.bld.WriteString(s)
+	p.comma = true
+}
+
+func (p *printer) text(r io.Reader) string {
+	var bld strings.Builder
+	io.Copy(&bld, r)
+	return strconv.Quote(bld.String())
+}
+
+func (p *printer) BeginObject() error {
+	p.value("{")
+	p.ends = append(p.ends, '}')
+	p.comma = false
+	return nil
+}
+
+func (p *printer) BeginArray() error {
+	if p.noarray {
+		p.value("null")
+		return SkipValue
+	}
+	p.value("[")
+	p.ends = append(p.ends, ']')
+	p.comma = false
+	return nil
+}
+
+func (p *printer) Key(r io.Reader) error {
+	k := p.text(r)
+	if strings.HasPrefix(k, "\"skip") {
+		return SkipValue
+	}
+	p.value(k + ":")
+	p.comma = false
+	p.noarray = k == "\"noarray\""
+	return nil
+}
+
+func (p *printer) End() error {
+	p.bld.WriteByte(p.ends[len(p.ends)-1])
+	p.ends = p.ends[:len(p.ends)-1]
+	p.comma = true
+	return nil
+}
+
+func (p *printer) String(r io.Reader) error {
+	p.value(p.text(r))
+	return nil
+}
+
+func (p *printer) Number(v *JsonValue) error {
+	raw, err := v.ValueNumRaw(nil)
+	p.value(string(raw))
+	return err
+}
+
+func (p *printer) Bool(b bool) error {
+	p.value(strconv.FormatBool(b))
+	return nil
+}
+
+func (p *printer) Null() error {
+	p.value("null")
+	return nil
+}
+
+// discard is a Handler that ignores every event.
+type discard struct{}
+
+func (discard) BeginObject() error        { return nil }
+func (discard) Key(r io.Reader) error     { return nil }
+func (discard) BeginArray() error         { return nil }
+func (discard) End() error                { return nil }
+func (discard) String(r io.Reader) error  { return nil }
+func (discard) Number(v *JsonValue) error { return nil }
+func (discard) Bool(b bool) error         { return nil }
+func (discard) Null() error               { return nil }
+
+func TestWalk(t *testing.T) {
+	json := "{\"a\": [1, -2.5e3, true, false, null, \"s\\n\"], \"skip1\": {\"x\": [1]}," +
+		" \"b\": {\"c\": {}, \"d\": []}, \"noarray\": [1, [2]], \"e\": \"f\"}"
+	v1, _ := Parse(strings.NewReader(json), 4)
+	p := &printer{}
+	e := Walk(v1, p)
+	assert(t, e != nil,
+		"1", e)
+	out := p.bld.String()
+	assert(t, out != "{\"a\":[1,-2.5e3,true,false,null,\"s\\n\"],\"b\":{\"c\":{},\"d\":[]},\"noarray\":null,\"e\":\"f\"}",
+		"2", out)
+	v1, _ = Parse(strings.NewReader("[1, 2, {\"a\": 3]"), 4)
+	e = Walk(v1, &printer{})
+	assert(t, e == nil || e.Error() != "Unexpected ']' at file offset 14, expected one of ',', '}'",
+		"3", e)
+	v1, _ = Parse(strings.NewReader("[\"a\", \"b\"] x"), 4)
+	v2, _ := v1.NextValue()
+	e = Walk(v2, &printer{})
+	assert(t, e != nil,
+		"4", e)
+	v2, e = v1.NextValue()
+	assert(t, v2.Type != String || e != nil,
+		"5", v2.Type, e)
+	r := strings.NewReader(json)
+	p1 := NewParser(r, 8)
+	allocs := testing.AllocsPerRun(100, func() {
+		r.Reset(json)
+		p1.Reset(r)
+		v1, _ := p1.Next()
+		Walk(v1, discard{})
+	})
+	// At most one, for the state of the walk.
+	assert(t, allocs > 1,
+		"6", allocs)
+}